@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/weeaa/jito-go/pkg"
 	"io"
 	"log"
 	"net/http"
@@ -20,12 +21,36 @@ func New(ctx context.Context, client *http.Client) *Client {
 	if client == nil {
 		client = &http.Client{}
 	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	c := Client{ctx: ctx, c: client}
 	return &c
 }
 
-// RetrieveBundleIDfromTransactionSignature returns the bundleID associated with the transaction signature provided, if existing.
+// NewWithOptions creates a Client from the pkg.ClientOption values relevant to HTTP: pkg.WithHTTPClient,
+// pkg.WithProxy, pkg.WithProxyPool and pkg.WithRequestTimeout.
+func NewWithOptions(ctx context.Context, opts ...pkg.ClientOption) (*Client, error) {
+	client, err := pkg.NewClientOptions(opts...).NewHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(ctx, client), nil
+}
+
+// NewWithProxy creates a Client whose requests go through the proxy (http://, https:// or socks5://).
+func NewWithProxy(ctx context.Context, client *http.Client, proxyURL string) (*Client, error) {
+	return NewWithOptions(ctx, pkg.WithHTTPClient(client), pkg.WithProxy(proxyURL))
+}
+
+// RetrieveBundleIDfromTransactionSignature is RetrieveBundleIDfromTransactionSignatureContext bound to the client context.
 func (api *Client) RetrieveBundleIDfromTransactionSignature(signature string) (string, error) {
+	return api.RetrieveBundleIDfromTransactionSignatureContext(api.ctx, signature)
+}
+
+// RetrieveBundleIDfromTransactionSignatureContext returns the bundleID associated with the transaction signature provided, if existing.
+func (api *Client) RetrieveBundleIDfromTransactionSignatureContext(ctx context.Context, signature string) (string, error) {
 	req := &http.Request{
 		Method: http.MethodGet,
 		URL: &url.URL{
@@ -36,7 +61,7 @@ func (api *Client) RetrieveBundleIDfromTransactionSignature(signature string) (s
 		Header: headers.Clone(),
 	}
 
-	resp, err := api.c.Do(req)
+	resp, err := api.c.Do(req.WithContext(ctx))
 	if err != nil {
 		return "", err
 	}
@@ -59,8 +84,13 @@ func (api *Client) RetrieveBundleIDfromTransactionSignature(signature string) (s
 	return out[0]["bundle_id"], nil
 }
 
-// RetrieveRecentBundles fetches a list of recent bundles from the Jito API within a specified timeframe and limit.
+// RetrieveRecentBundles is RetrieveRecentBundlesContext bound to the client context.
 func (api *Client) RetrieveRecentBundles(limit int, timeFrame Timeframe) (*RecentBundlesResponse, error) {
+	return api.RetrieveRecentBundlesContext(api.ctx, limit, timeFrame)
+}
+
+// RetrieveRecentBundlesContext fetches a list of recent bundles from the Jito API within a specified timeframe and limit.
+func (api *Client) RetrieveRecentBundlesContext(ctx context.Context, limit int, timeFrame Timeframe) (*RecentBundlesResponse, error) {
 	return nil, errors.New("i'm broken pls wait ser till im fixed")
 
 	params := &url.Values{
@@ -82,7 +112,7 @@ func (api *Client) RetrieveRecentBundles(limit int, timeFrame Timeframe) (*Recen
 	}
 	log.Println(req)
 
-	resp, err := api.c.Do(req)
+	resp, err := api.c.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -100,8 +130,13 @@ func (api *Client) RetrieveRecentBundles(limit int, timeFrame Timeframe) (*Recen
 	return &bundles, nil
 }
 
-// GetBundleInfo returns information associated with a bundle ID.
+// GetBundleInfo is GetBundleInfoContext bound to the client context.
 func (api *Client) GetBundleInfo(bundleID string) (*GetBundleInfoResponse, error) {
+	return api.GetBundleInfoContext(api.ctx, bundleID)
+}
+
+// GetBundleInfoContext returns information associated with a bundle ID.
+func (api *Client) GetBundleInfoContext(ctx context.Context, bundleID string) (*GetBundleInfoResponse, error) {
 	req := &http.Request{
 		Method: http.MethodGet,
 		URL: &url.URL{
@@ -112,7 +147,7 @@ func (api *Client) GetBundleInfo(bundleID string) (*GetBundleInfoResponse, error
 		Header: headers.Clone(),
 	}
 
-	resp, err := api.c.Do(req)
+	resp, err := api.c.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +169,13 @@ func (api *Client) GetLargestBundlesBySigner() error {
 	return errors.New("pls impl me")
 }
 
+// GetDailyMevRewards is GetDailyMevRewardsContext bound to the client context.
 func (api *Client) GetDailyMevRewards() (*[]GetDailyMevRewardsResponse, error) {
+	return api.GetDailyMevRewardsContext(api.ctx)
+}
+
+// GetDailyMevRewardsContext returns the MEV rewards and tips of every day.
+func (api *Client) GetDailyMevRewardsContext(ctx context.Context) (*[]GetDailyMevRewardsResponse, error) {
 	req := &http.Request{
 		Method: http.MethodGet,
 		URL: &url.URL{
@@ -145,7 +186,7 @@ func (api *Client) GetDailyMevRewards() (*[]GetDailyMevRewardsResponse, error) {
 		Header: headers.Clone(),
 	}
 
-	resp, err := api.c.Do(req)
+	resp, err := api.c.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -3,13 +3,62 @@ package blockengine_client
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/weeaa/jito-go"
 	"github.com/weeaa/jito-go/pb"
 	"github.com/weeaa/jito-go/pkg"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"math"
+	"time"
 )
 
+// NewRelayerWithOptions creates a new block engine client authenticated with the relayer role, pkg.WithPrivateKey is required.
+func NewRelayerWithOptions(ctx context.Context, opts ...pkg.ClientOption) (*Relayer, error) {
+	o := pkg.NewClientOptions(opts...)
+	if o.PrivateKey == nil {
+		return nil, errors.New("relayer requires a private key, use pkg.WithPrivateKey")
+	}
+
+	endpoint, err := o.ResolveEndpoint(ctx, func(info jito_go.JitoEndpointInfo) string { return info.BlockEngineURL })
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle := pkg.NewLifecycle(ctx, 16)
+	supervisor, err := o.Dial(lifecycle, endpoint)
+	if err != nil {
+		lifecycle.Close()
+		return nil, err
+	}
+
+	blockEngineRelayerClient := jito_pb.NewBlockEngineRelayerClient(supervisor)
+	authService := pkg.NewAuthenticationService(supervisor, o.PrivateKey)
+	authService.Events = lifecycle.Events()
+	lifecycle.OnClose(authService.Close)
+	if err = authService.AuthenticateAndRefreshContext(lifecycle.Context(), jito_pb.Role_RELAYER); err != nil {
+		lifecycle.Close()
+		return nil, err
+	}
+
+	relayer := &Relayer{
+		GrpcConn:   supervisor.Conn(),
+		Supervisor: supervisor,
+		Client:     blockEngineRelayerClient,
+		Auth:       authService,
+		ErrChan:    lifecycle.Errors(),
+		lifecycle:  lifecycle,
+		streamOpts: o.StreamOptions(),
+	}
+
+	return relayer, nil
+}
+
+// NewRelayer creates a new block engine client authenticated with the relayer role, use NewRelayerWithOptions and
+// pkg.WithProxy to connect through a proxy.
 func NewRelayer(
 	ctx context.Context,
 	grpcDialURL string,
@@ -18,38 +67,67 @@ func NewRelayer(
 	opts ...grpc.DialOption,
 ) (
 	*Relayer, error) {
+	return NewRelayerWithOptions(ctx,
+		pkg.WithEndpoint(grpcDialURL),
+		pkg.WithPrivateKey(privateKey),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
+}
+
+// Close stops every goroutine of the client, waits for them and closes the connection, it can be called several times.
+func (c *Relayer) Close() error {
+	return c.lifecycle.Close()
+}
 
-	if tlsConfig != nil {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+// Events returns the event bus of the client, subscribe with pkg.Subscribe.
+func (c *Relayer) Events() *pkg.EventBus {
+	return c.lifecycle.Events()
+}
+
+// NewValidatorWithOptions creates a new block engine client authenticated with the validator role, pkg.WithPrivateKey is required.
+func NewValidatorWithOptions(ctx context.Context, opts ...pkg.ClientOption) (*Validator, error) {
+	o := pkg.NewClientOptions(opts...)
+	if o.PrivateKey == nil {
+		return nil, errors.New("validator requires a private key, use pkg.WithPrivateKey")
 	}
 
-	chErr := make(chan error)
-	conn, err := pkg.CreateAndObserveGRPCConn(ctx, chErr, grpcDialURL, opts...)
+	endpoint, err := o.ResolveEndpoint(ctx, func(info jito_go.JitoEndpointInfo) string { return info.BlockEngineURL })
 	if err != nil {
 		return nil, err
 	}
 
-	blockEngineRelayerClient := jito_pb.NewBlockEngineRelayerClient(conn)
-	authService := pkg.NewAuthenticationService(conn, privateKey)
-	if err = authService.AuthenticateAndRefresh(jito_pb.Role_RELAYER); err != nil {
+	lifecycle := pkg.NewLifecycle(ctx, 16)
+	supervisor, err := o.Dial(lifecycle, endpoint)
+	if err != nil {
+		lifecycle.Close()
 		return nil, err
 	}
 
-	return &Relayer{
-		GrpcConn: conn,
-		Client:   blockEngineRelayerClient,
-		Auth:     pkg.NewAuthenticationService(conn, privateKey),
-		ErrChan:  chErr,
-	}, nil
-}
+	blockEngineValidatorClient := jito_pb.NewBlockEngineValidatorClient(supervisor)
+	authService := pkg.NewAuthenticationService(supervisor, o.PrivateKey)
+	authService.Events = lifecycle.Events()
+	lifecycle.OnClose(authService.Close)
+	if err = authService.AuthenticateAndRefreshContext(lifecycle.Context(), jito_pb.Role_VALIDATOR); err != nil {
+		lifecycle.Close()
+		return nil, err
+	}
 
-func (c *Relayer) Close() error {
-	close(c.ErrChan)
-	return c.GrpcConn.Close()
+	validator := &Validator{
+		GrpcConn:   supervisor.Conn(),
+		Supervisor: supervisor,
+		Client:     blockEngineValidatorClient,
+		Auth:       authService,
+		ErrChan:    lifecycle.Errors(),
+		lifecycle:  lifecycle,
+		streamOpts: o.StreamOptions(),
+	}
+
+	return validator, nil
 }
 
+// NewValidator creates a new block engine client authenticated with the validator role, use NewValidatorWithOptions and
+// pkg.WithProxy to connect through a proxy.
 func NewValidator(
 	ctx context.Context,
 	grpcDialURL string,
@@ -58,214 +136,620 @@ func NewValidator(
 	opts ...grpc.DialOption,
 ) (
 	*Validator, error) {
+	return NewValidatorWithOptions(ctx,
+		pkg.WithEndpoint(grpcDialURL),
+		pkg.WithPrivateKey(privateKey),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
+}
 
-	if tlsConfig != nil {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
-	}
+// Close stops every goroutine of the client, waits for them and closes the connection, it can be called several times.
+func (c *Validator) Close() error {
+	return c.lifecycle.Close()
+}
 
-	chErr := make(chan error)
-	conn, err := pkg.CreateAndObserveGRPCConn(ctx, chErr, grpcDialURL, opts...)
-	if err != nil {
-		return nil, err
-	}
+// Events returns the event bus of the client, subscribe with pkg.Subscribe.
+func (c *Validator) Events() *pkg.EventBus {
+	return c.lifecycle.Events()
+}
 
-	blockEngineValidatorClient := jito_pb.NewBlockEngineValidatorClient(conn)
-	authService := pkg.NewAuthenticationService(conn, privateKey)
-	if err = authService.AuthenticateAndRefresh(jito_pb.Role_VALIDATOR); err != nil {
-		return nil, err
-	}
+// streamOptions prepends StreamTimeout and the client defaults to opts and publishes the stream events on the client bus.
+func streamOptions(defaults []pkg.StreamOption, lifecycle *pkg.Lifecycle, name string, opts []pkg.StreamOption) []pkg.StreamOption {
+	streamOpts := append([]pkg.StreamOption{pkg.WithHeartbeatTimeout(StreamTimeout)}, defaults...)
+	return append(append(streamOpts, pkg.WithEventBus(lifecycle.Events(), name)), opts...)
+}
 
-	return &Validator{
-		GrpcConn: conn,
-		Client:   blockEngineValidatorClient,
-		Auth:     authService,
-		ErrChan:  chErr,
-	}, nil
+// SubscribePackets is SubscribePacketsContext bound to the client lifetime.
+func (c *Validator) SubscribePackets(opts ...grpc.CallOption) (jito_pb.BlockEngineValidator_SubscribePacketsClient, error) {
+	return c.SubscribePacketsContext(c.lifecycle.Context(), opts...)
 }
 
-func (c *Validator) Close() error {
-	close(c.ErrChan)
-	return c.GrpcConn.Close()
+// SubscribePacketsContext opens the packet stream, it ends when ctx is done.
+func (c *Validator) SubscribePacketsContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.BlockEngineValidator_SubscribePacketsClient, error) {
+	return c.Client.SubscribePackets(c.Auth.AuthorizeContext(ctx), &jito_pb.SubscribePacketsRequest{}, opts...)
 }
 
-func (c *Validator) SubscribePackets() (jito_pb.BlockEngineValidator_SubscribePacketsClient, error) {
-	return c.Client.SubscribePackets(c.Auth.GrpcCtx, &jito_pb.SubscribePacketsRequest{})
+// OnPacketSubscription is a wrapper of SubscribePackets, the stream is re-opened whenever it fails or stays silent for
+// StreamTimeout.
+// pkg.WithBuffer prevents a slow consumer from stalling the stream, pkg.WithStats exposes the dropped messages counter.
+func (c *Validator) OnPacketSubscription(ctx context.Context, opts ...pkg.StreamOption) (<-chan *jito_pb.SubscribePacketsResponse, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	stream, err := pkg.NewResilientStream(ctx, func(ctx context.Context) (pkg.Receiver[*jito_pb.SubscribePacketsResponse], error) {
+		return c.SubscribePacketsContext(ctx)
+	}, streamOptions(c.streamOpts, c.lifecycle, "OnPacketSubscription", opts)...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	c.lifecycle.Go(func(context.Context) {
+		<-stream.Done()
+		cancel()
+	})
+
+	return stream.Data(), stream.Errors(), nil
 }
 
-// OnPacketSubscription is a wrapper of SubscribePackets.
-func (c *Validator) OnPacketSubscription(ctx context.Context) (<-chan *jito_pb.SubscribePacketsResponse, <-chan error, error) {
-	sub, err := c.SubscribePackets()
+// OnDecodedPacketSubscription is OnPacketSubscription yielding the transactions of every batch along with their metadata
+// and the batch timestamp. Packets rejected by one of filters, e.g. pkg.SkipVotes() or pkg.MinSenderStake(stake), are
+// skipped before being decoded. Stream errors and *pkg.PacketDecodeError values are sent on the error channel, dropped when it is full.
+func (c *Validator) OnDecodedPacketSubscription(ctx context.Context, filters []pkg.PacketFilter, opts ...pkg.StreamOption) (<-chan []pkg.Packet, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	responses, streamErrs, err := c.OnPacketSubscription(ctx, opts...)
 	if err != nil {
+		cancel()
 		return nil, nil, err
 	}
 
-	chPackets := make(chan *jito_pb.SubscribePacketsResponse)
-	chErr := make(chan error)
+	chPackets := make(chan []pkg.Packet)
+	chErr := make(chan error, 16)
+
+	c.lifecycle.Go(func(context.Context) {
+		defer cancel()
+		defer close(chPackets)
+		defer close(chErr)
 
-	go func() {
 		for {
 			select {
-			case <-ctx.Done():
-				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
+			case err, ok := <-streamErrs:
+				if !ok {
+					streamErrs = nil
+					continue
+				}
+				pkg.DispatchError(chErr, err)
+			case resp, ok := <-responses:
+				if !ok {
+					return
+				}
+
+				packets, errs := pkg.DecodePackets(resp.GetHeader(), resp.GetBatch().GetPackets(), filters...)
+				for _, err = range errs {
+					pkg.DispatchError(chErr, err)
+				}
+				if len(packets) == 0 {
 					continue
 				}
 
-				chPackets <- resp
+				select {
+				case chPackets <- packets:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
-	}()
+	})
 
 	return chPackets, chErr, nil
 }
 
-func (c *Validator) SubscribeBundles() (jito_pb.BlockEngineValidator_SubscribeBundlesClient, error) {
-	return c.Client.SubscribeBundles(c.Auth.GrpcCtx, &jito_pb.SubscribeBundlesRequest{})
+// SubscribeBundles is SubscribeBundlesContext bound to the client lifetime.
+func (c *Validator) SubscribeBundles(opts ...grpc.CallOption) (jito_pb.BlockEngineValidator_SubscribeBundlesClient, error) {
+	return c.SubscribeBundlesContext(c.lifecycle.Context(), opts...)
+}
+
+// SubscribeBundlesContext opens the bundle stream, it ends when ctx is done.
+func (c *Validator) SubscribeBundlesContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.BlockEngineValidator_SubscribeBundlesClient, error) {
+	return c.Client.SubscribeBundles(c.Auth.AuthorizeContext(ctx), &jito_pb.SubscribeBundlesRequest{}, opts...)
+}
+
+// OnBundleSubscription is a wrapper of SubscribeBundles, the stream is re-opened whenever it fails or stalls.
+// Buffering works the same as in OnPacketSubscription.
+func (c *Validator) OnBundleSubscription(ctx context.Context, opts ...pkg.StreamOption) (<-chan []*jito_pb.BundleUuid, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	stream, err := pkg.NewResilientStream(ctx, func(ctx context.Context) (pkg.Receiver[[]*jito_pb.BundleUuid], error) {
+		sub, err := c.SubscribeBundlesContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return pkg.MapReceiver(sub, (*jito_pb.SubscribeBundlesResponse).GetBundles), nil
+	}, streamOptions(c.streamOpts, c.lifecycle, "OnBundleSubscription", opts)...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	c.lifecycle.Go(func(context.Context) {
+		<-stream.Done()
+		cancel()
+	})
+
+	return stream.Data(), stream.Errors(), nil
 }
 
-// OnBundleSubscription is a wrapper of SubscribeBundles.
-func (c *Validator) OnBundleSubscription(ctx context.Context) (<-chan []*jito_pb.BundleUuid, <-chan error, error) {
-	sub, err := c.SubscribeBundles()
+// OnDecodedBundleSubscription is OnBundleSubscription yielding each bundle decoded, with its tipper, tip account and amount
+// found among tipAccounts (jito_go.MainnetTipAccounts when nil). Bundles forwarded again, e.g. after a reconnection,
+// are dropped using the last pkg.DefaultBundleDedupSize UUIDs. Errors are sent like in OnDecodedPacketSubscription.
+func (c *Validator) OnDecodedBundleSubscription(ctx context.Context, tipAccounts []solana.PublicKey, opts ...pkg.StreamOption) (<-chan pkg.DecodedBundle, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	bundles, streamErrs, err := c.OnBundleSubscription(ctx, opts...)
 	if err != nil {
+		cancel()
 		return nil, nil, err
 	}
 
-	chBundleUuid := make(chan []*jito_pb.BundleUuid)
-	chErr := make(chan error)
+	chBundle := make(chan pkg.DecodedBundle)
+	chErr := make(chan error, 16)
+	dedup := pkg.NewBundleDeduplicator(pkg.DefaultBundleDedupSize)
+
+	c.lifecycle.Go(func(context.Context) {
+		defer cancel()
+		defer close(chBundle)
+		defer close(chErr)
 
-	go func() {
 		for {
 			select {
-			case <-ctx.Done():
-				return
-			case <-c.Auth.GrpcCtx.Done():
-				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
+			case err, ok := <-streamErrs:
+				if !ok {
+					streamErrs = nil
 					continue
 				}
+				pkg.DispatchError(chErr, err)
+			case batch, ok := <-bundles:
+				if !ok {
+					return
+				}
 
-				chBundleUuid <- resp.Bundles
+				for _, bundle := range batch {
+					if dedup.Seen(bundle.GetUuid()) {
+						continue
+					}
+
+					decoded, errs := pkg.DecodeBundle(bundle, tipAccounts)
+					for _, err = range errs {
+						pkg.DispatchError(chErr, fmt.Errorf("bundle %s: %w", bundle.GetUuid(), err))
+					}
+
+					select {
+					case chBundle <- decoded:
+					case <-ctx.Done():
+						return
+					}
+				}
 			}
 		}
-	}()
+	})
 
-	return chBundleUuid, chErr, nil
+	return chBundle, chErr, nil
 }
 
+// GetBlockBuilderFeeInfo is GetBlockBuilderFeeInfoContext bound to the client lifetime.
 func (c *Validator) GetBlockBuilderFeeInfo(opts ...grpc.CallOption) (*jito_pb.BlockBuilderFeeInfoResponse, error) {
-	return c.Client.GetBlockBuilderFeeInfo(c.Auth.GrpcCtx, &jito_pb.BlockBuilderFeeInfoRequest{}, opts...)
+	return c.GetBlockBuilderFeeInfoContext(c.lifecycle.Context(), opts...)
 }
 
-func (c *Relayer) SubscribeAccountsOfInterest(opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_SubscribeAccountsOfInterestClient, error) {
-	return c.Client.SubscribeAccountsOfInterest(c.Auth.GrpcCtx, &jito_pb.AccountsOfInterestRequest{}, opts...)
+// GetBlockBuilderFeeInfoContext returns the pubkey and the commission of the block builder.
+func (c *Validator) GetBlockBuilderFeeInfoContext(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.BlockBuilderFeeInfoResponse, error) {
+	return c.Client.GetBlockBuilderFeeInfo(c.Auth.AuthorizeContext(ctx), &jito_pb.BlockBuilderFeeInfoRequest{}, opts...)
 }
 
-// OnSubscribeAccountsOfInterest is a wrapper of SubscribeAccountsOfInterest.
-func (c *Relayer) OnSubscribeAccountsOfInterest(ctx context.Context) (<-chan *jito_pb.AccountsOfInterestUpdate, <-chan error, error) {
-	sub, err := c.SubscribeAccountsOfInterest()
+// NewBlockBuilderCommission returns a BlockBuilderCommission refreshing the fee info once older than ttl, 1 minute when not positive.
+func (c *Validator) NewBlockBuilderCommission(ttl time.Duration) *BlockBuilderCommission {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &BlockBuilderCommission{validator: c, ttl: ttl}
+}
+
+// FeeInfo returns the cached block builder fee info, fetching it again once expired.
+func (b *BlockBuilderCommission) FeeInfo(ctx context.Context) (pkg.BlockBuilderFeeInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.fetchedAt.IsZero() && time.Since(b.fetchedAt) < b.ttl {
+		return b.info, nil
+	}
+
+	resp, err := b.validator.GetBlockBuilderFeeInfoContext(ctx)
 	if err != nil {
-		return nil, nil, err
+		return pkg.BlockBuilderFeeInfo{}, err
 	}
 
-	chAccountOfInterest := make(chan *jito_pb.AccountsOfInterestUpdate)
-	chErr := make(chan error)
+	info, err := pkg.NewBlockBuilderFeeInfo(resp)
+	if err != nil {
+		return pkg.BlockBuilderFeeInfo{}, err
+	}
+	b.info, b.fetchedAt = info, time.Now()
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-c.Auth.GrpcCtx.Done():
-				return
-			default:
-				resp, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
+	return info, nil
+}
 
-				chAccountOfInterest <- resp
-			}
-		}
-	}()
+// Share returns the lamports owed to the block builder out of tips.
+func (b *BlockBuilderCommission) Share(ctx context.Context, tips ...uint64) (uint64, error) {
+	info, err := b.FeeInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return info.Share(tips...), nil
+}
 
-	return chAccountOfInterest, chErr, nil
+// PaymentInstruction transfers the block builder share of tips from the validator account from.
+func (b *BlockBuilderCommission) PaymentInstruction(ctx context.Context, from solana.PublicKey, tips ...uint64) (solana.Instruction, error) {
+	info, err := b.FeeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return info.PaymentInstruction(from, tips...), nil
 }
 
+// ChangeBlockBuilderInstruction builds the change_block_builder instruction of the tip payment program programID,
+// e.g. jito_go.MainnetTipPaymentProgram, pointing it to the current block builder and commission.
+// The current config of the program is read with rpcClient.
+func (b *BlockBuilderCommission) ChangeBlockBuilderInstruction(ctx context.Context, rpcClient *rpc.Client, programID, signer solana.PublicKey) (solana.Instruction, error) {
+	info, err := b.FeeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := pkg.GetTipPaymentConfig(ctx, rpcClient, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.NewChangeBlockBuilderInstruction(programID, *current, info, signer)
+}
+
+// SubscribeAccountsOfInterest is SubscribeAccountsOfInterestContext bound to the client lifetime.
+func (c *Relayer) SubscribeAccountsOfInterest(opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_SubscribeAccountsOfInterestClient, error) {
+	return c.SubscribeAccountsOfInterestContext(c.lifecycle.Context(), opts...)
+}
+
+// SubscribeAccountsOfInterestContext opens the accounts of interest stream, it ends when ctx is done.
+func (c *Relayer) SubscribeAccountsOfInterestContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_SubscribeAccountsOfInterestClient, error) {
+	return c.Client.SubscribeAccountsOfInterest(c.Auth.AuthorizeContext(ctx), &jito_pb.AccountsOfInterestRequest{}, opts...)
+}
+
+// OnSubscribeAccountsOfInterest is a wrapper of SubscribeAccountsOfInterest, the stream is re-opened whenever it fails or stalls.
+func (c *Relayer) OnSubscribeAccountsOfInterest(ctx context.Context, opts ...pkg.StreamOption) (<-chan *jito_pb.AccountsOfInterestUpdate, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	stream, err := pkg.NewResilientStream(ctx, func(ctx context.Context) (pkg.Receiver[*jito_pb.AccountsOfInterestUpdate], error) {
+		return c.SubscribeAccountsOfInterestContext(ctx)
+	}, streamOptions(c.streamOpts, c.lifecycle, "OnSubscribeAccountsOfInterest", opts)...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	c.lifecycle.Go(func(context.Context) {
+		<-stream.Done()
+		cancel()
+	})
+
+	return stream.Data(), stream.Errors(), nil
+}
+
+// SubscribeProgramsOfInterest is SubscribeProgramsOfInterestContext bound to the client lifetime.
 func (c *Relayer) SubscribeProgramsOfInterest(opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_SubscribeProgramsOfInterestClient, error) {
-	return c.Client.SubscribeProgramsOfInterest(c.Auth.GrpcCtx, &jito_pb.ProgramsOfInterestRequest{}, opts...)
+	return c.SubscribeProgramsOfInterestContext(c.lifecycle.Context(), opts...)
+}
+
+// SubscribeProgramsOfInterestContext opens the programs of interest stream, it ends when ctx is done.
+func (c *Relayer) SubscribeProgramsOfInterestContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_SubscribeProgramsOfInterestClient, error) {
+	return c.Client.SubscribeProgramsOfInterest(c.Auth.AuthorizeContext(ctx), &jito_pb.ProgramsOfInterestRequest{}, opts...)
 }
 
-// OnSubscribeProgramsOfInterest is a wrapper of SubscribeProgramsOfInterest.
-func (c *Relayer) OnSubscribeProgramsOfInterest(ctx context.Context) (<-chan *jito_pb.ProgramsOfInterestUpdate, <-chan error, error) {
-	sub, err := c.SubscribeProgramsOfInterest()
+// OnSubscribeProgramsOfInterest is a wrapper of SubscribeProgramsOfInterest, the stream is re-opened whenever it fails or stalls.
+func (c *Relayer) OnSubscribeProgramsOfInterest(ctx context.Context, opts ...pkg.StreamOption) (<-chan *jito_pb.ProgramsOfInterestUpdate, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	stream, err := pkg.NewResilientStream(ctx, func(ctx context.Context) (pkg.Receiver[*jito_pb.ProgramsOfInterestUpdate], error) {
+		return c.SubscribeProgramsOfInterestContext(ctx)
+	}, streamOptions(c.streamOpts, c.lifecycle, "OnSubscribeProgramsOfInterest", opts)...)
 	if err != nil {
+		cancel()
 		return nil, nil, err
 	}
+	c.lifecycle.Go(func(context.Context) {
+		<-stream.Done()
+		cancel()
+	})
 
-	chProgramsOfInterest := make(chan *jito_pb.ProgramsOfInterestUpdate)
-	chErr := make(chan error)
+	return stream.Data(), stream.Errors(), nil
+}
 
-	go func() {
-		for {
+// NewInterestRegistry returns a pkg.InterestRegistry fed by the accounts and programs of interest streams until ctx is done
+// or the client is closed. Keys not sent again within ttl expire, pkg.DefaultInterestTTL when not positive.
+// Use registry.Matches to decide whether a transaction should be forwarded on the expiring packet stream.
+// Stream errors and invalid keys are sent on the error channel, dropped when it is full.
+func (c *Relayer) NewInterestRegistry(ctx context.Context, ttl time.Duration, opts ...pkg.StreamOption) (*pkg.InterestRegistry, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	accounts, accountErrs, err := c.OnSubscribeAccountsOfInterest(ctx, opts...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	programs, programErrs, err := c.OnSubscribeProgramsOfInterest(ctx, opts...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	registry := pkg.NewInterestRegistry(ttl)
+	chErr := make(chan error, 16)
+
+	c.lifecycle.Go(func(context.Context) {
+		defer cancel()
+		defer close(chErr)
+
+		for accounts != nil || programs != nil {
 			select {
-			case <-ctx.Done():
-				return
-			default:
-				subInfo, err := sub.Recv()
-				if err != nil {
-					chErr <- err
+			case err, ok := <-accountErrs:
+				if !ok {
+					accountErrs = nil
 					continue
 				}
-
-				chProgramsOfInterest <- subInfo
+				pkg.DispatchError(chErr, err)
+			case err, ok := <-programErrs:
+				if !ok {
+					programErrs = nil
+					continue
+				}
+				pkg.DispatchError(chErr, err)
+			case update, ok := <-accounts:
+				if !ok {
+					accounts = nil
+					continue
+				}
+				if invalid := registry.Accounts.AddStrings(update.GetAccounts()); invalid != nil {
+					pkg.DispatchError(chErr, fmt.Errorf("invalid accounts of interest: %v", invalid))
+				}
+			case update, ok := <-programs:
+				if !ok {
+					programs = nil
+					continue
+				}
+				if invalid := registry.Programs.AddStrings(update.GetPrograms()); invalid != nil {
+					pkg.DispatchError(chErr, fmt.Errorf("invalid programs of interest: %v", invalid))
+				}
 			}
 		}
-	}()
+	})
 
-	return chProgramsOfInterest, chErr, nil
+	return registry, chErr, nil
 }
 
+// StartExpiringPacketStream is StartExpiringPacketStreamContext bound to the client lifetime.
 func (c *Relayer) StartExpiringPacketStream(opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_StartExpiringPacketStreamClient, error) {
-	return c.Client.StartExpiringPacketStream(c.Auth.GrpcCtx, opts...)
+	return c.StartExpiringPacketStreamContext(c.lifecycle.Context(), opts...)
 }
 
-// OnStartExpiringPacketStream is a wrapper of StartExpiringPacketStream.
+// StartExpiringPacketStreamContext opens the bidirectional packet stream, it ends when ctx is done.
+func (c *Relayer) StartExpiringPacketStreamContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.BlockEngineRelayer_StartExpiringPacketStreamClient, error) {
+	return c.Client.StartExpiringPacketStream(c.Auth.AuthorizeContext(ctx), opts...)
+}
+
+// OnStartExpiringPacketStream is a wrapper of StartExpiringPacketStream, both channels are closed once the stream ends.
 func (c *Relayer) OnStartExpiringPacketStream(ctx context.Context) (<-chan *jito_pb.StartExpiringPacketStreamResponse, <-chan error, error) {
-	sub, err := c.StartExpiringPacketStream()
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	sub, err := c.StartExpiringPacketStreamContext(ctx)
 	if err != nil {
+		cancel()
 		return nil, nil, err
 	}
 
 	chPacket := make(chan *jito_pb.StartExpiringPacketStreamResponse)
-	chErr := make(chan error)
+	chErr := make(chan error, 1)
+
+	c.lifecycle.Go(func(context.Context) {
+		defer cancel()
+		defer close(chPacket)
+		defer close(chErr)
 
-	go func() {
 		for {
+			recv, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					chErr <- err
+				}
+				return
+			}
+
 			select {
+			case chPacket <- recv:
 			case <-ctx.Done():
 				return
-			case <-c.Auth.GrpcCtx.Done():
+			}
+		}
+	})
+
+	return chPacket, chErr, nil
+}
+
+var (
+	ErrSenderClosed     = errors.New("expiring packet sender closed")
+	ErrHeartbeatTimeout = errors.New("no heartbeat received from the block engine")
+)
+
+// NewExpiringPacketSender opens the StartExpiringPacketStream, the sender stops when ctx is done, on the first stream
+// error or when the block engine misses heartbeats, see Done and Err.
+func (c *Relayer) NewExpiringPacketSender(ctx context.Context, config ExpiringPacketSenderConfig) (*ExpiringPacketSender, error) {
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 500 * time.Millisecond
+	}
+	if config.HeartbeatTimeout <= 0 {
+		config.HeartbeatTimeout = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	stream, err := c.StartExpiringPacketStreamContext(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &ExpiringPacketSender{
+		stream: stream,
+		config: config,
+		queue:  make(chan *jito_pb.PacketBatchUpdate, config.QueueSize),
+		acks:   make(chan uint64, 16),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.lastAck.Store(time.Now().UnixNano())
+
+	c.lifecycle.Go(func(context.Context) { s.write(ctx) })
+	c.lifecycle.Go(func(context.Context) { s.read() })
+
+	return s, nil
+}
+
+// fail stops the sender with err, only the first error is kept.
+func (s *ExpiringPacketSender) fail(err error) {
+	s.once.Do(func() {
+		s.err = err
+		s.cancel()
+		close(s.done)
+	})
+}
+
+func (s *ExpiringPacketSender) write(ctx context.Context) {
+	ticker := time.NewTicker(s.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.stream.CloseSend()
+			s.fail(ErrSenderClosed)
+			return
+		case update := <-s.queue:
+			if err := s.stream.Send(update); err != nil {
+				s.fail(fmt.Errorf("failed to send packet batch: %w", err))
+				return
+			}
+			s.batches.Add(1)
+			s.packets.Add(uint64(len(update.GetBatches().GetBatch().GetPackets())))
+		case <-ticker.C:
+			if time.Since(time.Unix(0, s.lastAck.Load())) > s.config.HeartbeatTimeout {
+				s.fail(ErrHeartbeatTimeout)
 				return
-			default:
-				recv, err := sub.Recv()
-				if err != nil {
-					chErr <- err
-					continue
-				}
+			}
 
-				chPacket <- recv
+			count := s.heartbeats.Add(1)
+			heartbeat := &jito_pb.PacketBatchUpdate{Msg: &jito_pb.PacketBatchUpdate_Heartbeat{Heartbeat: &jito_pb.Heartbeat{Count: count}}}
+			if err := s.stream.Send(heartbeat); err != nil {
+				s.fail(fmt.Errorf("failed to send heartbeat: %w", err))
+				return
 			}
 		}
-	}()
+	}
+}
 
-	return chPacket, chErr, nil
+func (s *ExpiringPacketSender) read() {
+	defer close(s.acks)
+
+	for {
+		resp, err := s.stream.Recv()
+		if err != nil {
+			s.fail(fmt.Errorf("expiring packet stream ended: %w", err))
+			return
+		}
+
+		s.heartbeatAcks.Add(1)
+		s.lastAck.Store(time.Now().UnixNano())
+
+		select {
+		case s.acks <- resp.GetHeartbeat().GetCount():
+		default:
+		}
+	}
+}
+
+// Send queues transactions as one batch the block engine drops once expiry has elapsed, a negative expiry is sent as 0.
+// It blocks while the queue is full and fails once the sender is done.
+func (s *ExpiringPacketSender) Send(batch []*solana.Transaction, expiry time.Duration) error {
+	return s.SendContext(context.Background(), batch, expiry)
+}
+
+// SendContext is Send giving up when ctx is done.
+func (s *ExpiringPacketSender) SendContext(ctx context.Context, batch []*solana.Transaction, expiry time.Duration) error {
+	packets, err := pkg.ConvertBatchTransactionToProtobufPacket(batch)
+	if err != nil {
+		return err
+	}
+	return s.SendPackets(ctx, packets, expiry)
+}
+
+// SendPackets is SendContext for packets already serialized, their Meta is kept.
+func (s *ExpiringPacketSender) SendPackets(ctx context.Context, packets []*jito_pb.Packet, expiry time.Duration) error {
+	update := &jito_pb.PacketBatchUpdate{Msg: &jito_pb.PacketBatchUpdate_Batches{Batches: &jito_pb.ExpiringPacketBatch{
+		Header:   &jito_pb.Header{Ts: timestamppb.Now()},
+		Batch:    &jito_pb.PacketBatch{Packets: packets},
+		ExpiryMs: uint32(max(0, min(expiry.Milliseconds(), math.MaxUint32))),
+	}}}
+
+	// a done sender never reads the queue again
+	select {
+	case <-s.done:
+		return s.Err()
+	default:
+	}
+
+	select {
+	case s.queue <- update:
+		return nil
+	case <-s.done:
+		return s.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Acks returns the heartbeat counts sent back by the block engine, values are dropped when it is full.
+// It is closed once the stream ends.
+func (s *ExpiringPacketSender) Acks() <-chan uint64 {
+	return s.acks
+}
+
+func (s *ExpiringPacketSender) Stats() ExpiringPacketSenderStats {
+	return ExpiringPacketSenderStats{
+		Batches:        s.batches.Load(),
+		Packets:        s.packets.Load(),
+		Heartbeats:     s.heartbeats.Load(),
+		HeartbeatsAcks: s.heartbeatAcks.Load(),
+		LastAck:        time.Unix(0, s.lastAck.Load()),
+		Queued:         len(s.queue),
+	}
+}
+
+// Done is closed once the sender stopped, Err then tells why.
+func (s *ExpiringPacketSender) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the sender stopped, nil while it is running.
+func (s *ExpiringPacketSender) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the sender, batches still queued are discarded.
+func (s *ExpiringPacketSender) Close() error {
+	s.fail(ErrSenderClosed)
+	return nil
 }
@@ -3,9 +3,15 @@ package blockengine_client
 import (
 	"context"
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"github.com/weeaa/jito-go/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -157,3 +163,155 @@ func Test_BlockEngineValidatorClient(t *testing.T) {
 		}
 	})
 }
+
+type fakeBlockEngineRelayer struct {
+	jito_pb.UnimplementedBlockEngineRelayerServer
+	silent   bool
+	packets  chan *jito_pb.ExpiringPacketBatch
+	accounts []string
+	programs []string
+}
+
+func (r *fakeBlockEngineRelayer) StartExpiringPacketStream(stream jito_pb.BlockEngineRelayer_StartExpiringPacketStreamServer) error {
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		if batch := update.GetBatches(); batch != nil {
+			r.packets <- batch
+		}
+		if heartbeat := update.GetHeartbeat(); heartbeat != nil && !r.silent {
+			if err = stream.Send(&jito_pb.StartExpiringPacketStreamResponse{Heartbeat: heartbeat}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (r *fakeBlockEngineRelayer) SubscribeAccountsOfInterest(_ *jito_pb.AccountsOfInterestRequest, stream jito_pb.BlockEngineRelayer_SubscribeAccountsOfInterestServer) error {
+	if err := stream.Send(&jito_pb.AccountsOfInterestUpdate{Accounts: r.accounts}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func (r *fakeBlockEngineRelayer) SubscribeProgramsOfInterest(_ *jito_pb.ProgramsOfInterestRequest, stream jito_pb.BlockEngineRelayer_SubscribeProgramsOfInterestServer) error {
+	if err := stream.Send(&jito_pb.ProgramsOfInterestUpdate{Programs: r.programs}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func newFakeRelayer(t *testing.T, ctx context.Context, server *fakeBlockEngineRelayer) *Relayer {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	jito_pb.RegisterBlockEngineRelayerServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	relayer := &Relayer{Client: jito_pb.NewBlockEngineRelayerClient(conn), Auth: &pkg.AuthenticationService{}, lifecycle: pkg.NewLifecycle(ctx, 0)}
+	t.Cleanup(func() { relayer.Close() })
+	return relayer
+}
+
+func TestExpiringPacketSender(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := &fakeBlockEngineRelayer{packets: make(chan *jito_pb.ExpiringPacketBatch, 8)}
+	sender, err := newFakeRelayer(t, ctx, server).NewExpiringPacketSender(ctx, ExpiringPacketSenderConfig{HeartbeatInterval: 10 * time.Millisecond})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	payer := solana.NewWallet()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+	}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, sender.Send([]*solana.Transaction{tx, tx}, 200*time.Millisecond))
+	batch := <-server.packets
+	assert.Equal(t, uint32(200), batch.GetExpiryMs())
+	assert.Len(t, batch.GetBatch().GetPackets(), 2)
+	assert.NotNil(t, batch.GetHeader().GetTs())
+
+	assert.NoError(t, sender.Send([]*solana.Transaction{tx}, -time.Second))
+	assert.Zero(t, (<-server.packets).GetExpiryMs())
+
+	assert.Positive(t, <-sender.Acks())
+	assert.Eventually(t, func() bool { return sender.Stats().Batches == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(3), sender.Stats().Packets)
+
+	assert.NoError(t, sender.Close())
+	<-sender.Done()
+	assert.ErrorIs(t, sender.Err(), ErrSenderClosed)
+	assert.ErrorIs(t, sender.Send(nil, time.Second), ErrSenderClosed)
+}
+
+func TestExpiringPacketSenderHeartbeatTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := &fakeBlockEngineRelayer{silent: true, packets: make(chan *jito_pb.ExpiringPacketBatch, 8)}
+	sender, err := newFakeRelayer(t, ctx, server).NewExpiringPacketSender(ctx, ExpiringPacketSenderConfig{
+		HeartbeatInterval: 10 * time.Millisecond,
+		HeartbeatTimeout:  50 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	<-sender.Done()
+	assert.ErrorIs(t, sender.Err(), ErrHeartbeatTimeout)
+}
+
+func TestInterestRegistry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	account := solana.NewWallet().PublicKey()
+	server := &fakeBlockEngineRelayer{accounts: []string{account.String(), "invalid"}, programs: []string{solana.SystemProgramID.String()}}
+
+	registry, chErr, err := newFakeRelayer(t, ctx, server).NewInterestRegistry(ctx, time.Minute)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.ErrorContains(t, <-chErr, "invalid accounts of interest")
+	assert.Eventually(t, func() bool {
+		return registry.Accounts.Contains(account) && registry.Programs.Contains(solana.SystemProgramID)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamOptions(t *testing.T) {
+	lifecycle := pkg.NewLifecycle(context.Background(), 0)
+	defer lifecycle.Close()
+
+	config := func(opts ...pkg.StreamOption) pkg.StreamConfig {
+		var c pkg.StreamConfig
+		for _, opt := range streamOptions(nil, lifecycle, "stream", opts) {
+			opt(&c)
+		}
+		return c
+	}
+
+	assert.Equal(t, StreamTimeout, config().HeartbeatTimeout)
+	assert.Equal(t, "stream", config().Name)
+	assert.Equal(t, time.Second, config(pkg.WithHeartbeatTimeout(time.Second)).HeartbeatTimeout)
+}
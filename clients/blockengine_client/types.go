@@ -1,27 +1,100 @@
 package blockengine_client
 
 import (
+	"context"
 	"github.com/weeaa/jito-go/pb"
 	"github.com/weeaa/jito-go/pkg"
 	"google.golang.org/grpc"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// StreamTimeout is how long a subscription may stay silent before it is reported as stalled and re-opened,
+// pkg.WithHeartbeatTimeout overrides it.
+const StreamTimeout = 30 * time.Second
+
 type Relayer struct {
-	GrpcConn *grpc.ClientConn
+	// GrpcConn is the connection dialed by the constructor, it is closed once the Supervisor recreates it.
+	//
+	// Deprecated: use Supervisor.Conn, which returns the live connection.
+	GrpcConn   *grpc.ClientConn
+	Supervisor *pkg.ConnSupervisor // Owns the connection and recreates it when it dies.
 
 	Client jito_pb.BlockEngineRelayerClient
 
 	Auth *pkg.AuthenticationService
 
-	ErrChan chan error // ErrChan is used for dispatching errors from functions executed within goroutines.
+	// ErrChan is used for dispatching errors from functions executed within goroutines, errors are dropped when it is full.
+	//
+	// Deprecated: subscribe to pkg.ErrorEvent on Events instead.
+	ErrChan chan error
+
+	streamOpts []pkg.StreamOption // defaults applied to every subscription.
+	lifecycle  *pkg.Lifecycle
 }
 
 type Validator struct {
-	GrpcConn *grpc.ClientConn
+	// GrpcConn is the connection dialed by the constructor, it is closed once the Supervisor recreates it.
+	//
+	// Deprecated: use Supervisor.Conn, which returns the live connection.
+	GrpcConn   *grpc.ClientConn
+	Supervisor *pkg.ConnSupervisor // Owns the connection and recreates it when it dies.
 
 	Client jito_pb.BlockEngineValidatorClient
 
 	Auth *pkg.AuthenticationService
 
-	ErrChan chan error // ErrChan is used for dispatching errors from functions executed within goroutines.
+	// ErrChan is used for dispatching errors from functions executed within goroutines, errors are dropped when it is full.
+	//
+	// Deprecated: subscribe to pkg.ErrorEvent on Events instead.
+	ErrChan chan error
+
+	streamOpts []pkg.StreamOption // defaults applied to every subscription.
+	lifecycle  *pkg.Lifecycle
+}
+
+// BlockBuilderCommission caches the block builder fee info of a Validator to compute what the block builder is owed.
+type BlockBuilderCommission struct {
+	validator *Validator
+	ttl       time.Duration
+
+	mu        sync.Mutex
+	info      pkg.BlockBuilderFeeInfo
+	fetchedAt time.Time
+}
+
+type ExpiringPacketSenderConfig struct {
+	HeartbeatInterval time.Duration // interval between two heartbeats sent to the block engine, defaults to 500ms.
+	HeartbeatTimeout  time.Duration // the sender fails when the block engine stays silent for longer, defaults to 5s.
+
+	// QueueSize is the amount of batches waiting to be written, Send blocks once it is reached. Defaults to 1024.
+	QueueSize int
+}
+
+// ExpiringPacketSenderStats are counters of an ExpiringPacketSender.
+type ExpiringPacketSenderStats struct {
+	Batches        uint64    // batches written to the stream.
+	Packets        uint64    // packets written to the stream.
+	Heartbeats     uint64    // heartbeats sent.
+	HeartbeatsAcks uint64    // heartbeats received from the block engine.
+	LastAck        time.Time // time of the last heartbeat received.
+	Queued         int       // batches waiting to be written.
+}
+
+// ExpiringPacketSender writes packet batches with an expiry on the StartExpiringPacketStream of a Relayer,
+// sends heartbeats on its own and watches the heartbeats the block engine answers with.
+type ExpiringPacketSender struct {
+	stream jito_pb.BlockEngineRelayer_StartExpiringPacketStreamClient
+	config ExpiringPacketSenderConfig
+	queue  chan *jito_pb.PacketBatchUpdate
+	acks   chan uint64
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	batches, packets, heartbeats, heartbeatAcks atomic.Uint64
+	lastAck                                     atomic.Int64
+
+	once sync.Once
+	err  error
 }
@@ -3,88 +3,198 @@ package relayer_client
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/gagliardetto/solana-go"
+	"github.com/weeaa/jito-go"
 	"github.com/weeaa/jito-go/pb"
 	"github.com/weeaa/jito-go/pkg"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 )
 
-func New(ctx context.Context, grpcDialURL string, privateKey solana.PrivateKey, tlsConfig *tls.Config, opts ...grpc.DialOption) (*Client, error) {
-	if tlsConfig != nil {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+// NewWithOptions creates a new relayer client, pkg.WithPrivateKey is required. Without pkg.WithEndpoint,
+// pkg.WithFastestRegion connects to the relayer of the fastest region.
+func NewWithOptions(ctx context.Context, opts ...pkg.ClientOption) (*Client, error) {
+	o := pkg.NewClientOptions(opts...)
+	if o.PrivateKey == nil {
+		return nil, errors.New("relayer client requires a private key, use pkg.WithPrivateKey")
+	}
+
+	endpoint, err := o.ResolveEndpoint(ctx, func(info jito_go.JitoEndpointInfo) string { return info.RelayerURL })
+	if err != nil {
+		return nil, err
 	}
 
-	chErr := make(chan error)
-	conn, err := pkg.CreateAndObserveGRPCConn(ctx, chErr, grpcDialURL, opts...)
+	lifecycle := pkg.NewLifecycle(ctx, 16)
+	supervisor, err := o.Dial(lifecycle, endpoint)
 	if err != nil {
+		lifecycle.Close()
 		return nil, err
 	}
 
-	relayerClient := jito_pb.NewRelayerClient(conn)
-	authService := pkg.NewAuthenticationService(conn, privateKey)
-	if err = authService.AuthenticateAndRefresh(jito_pb.Role_RELAYER); err != nil {
+	relayerClient := jito_pb.NewRelayerClient(supervisor)
+	authService := pkg.NewAuthenticationService(supervisor, o.PrivateKey)
+	authService.Events = lifecycle.Events()
+	lifecycle.OnClose(authService.Close)
+	if err = authService.AuthenticateAndRefreshContext(lifecycle.Context(), jito_pb.Role_RELAYER); err != nil {
+		lifecycle.Close()
 		return nil, err
 	}
 
-	return &Client{
-		GrpcConn: conn,
-		Relayer:  relayerClient,
-		Auth:     authService,
-		ErrChan:  chErr,
-	}, nil
+	client := &Client{
+		GrpcConn:   supervisor.Conn(),
+		Supervisor: supervisor,
+		Relayer:    relayerClient,
+		Auth:       authService,
+		ErrChan:    lifecycle.Errors(),
+		lifecycle:  lifecycle,
+		streamOpts: o.StreamOptions(),
+	}
+
+	return client, nil
+}
+
+// New creates a new relayer client, use NewWithOptions and pkg.WithProxy to connect through a proxy.
+func New(ctx context.Context, grpcDialURL string, privateKey solana.PrivateKey, tlsConfig *tls.Config, opts ...grpc.DialOption) (*Client, error) {
+	return NewWithOptions(ctx,
+		pkg.WithEndpoint(grpcDialURL),
+		pkg.WithPrivateKey(privateKey),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
 }
 
+// Close stops every goroutine of the client, waits for them and closes the connection, it can be called several times.
 func (c *Client) Close() error {
-	close(c.ErrChan)
-	return c.GrpcConn.Close()
+	return c.lifecycle.Close()
 }
 
+// Events returns the event bus of the client, subscribe with pkg.Subscribe.
+func (c *Client) Events() *pkg.EventBus {
+	return c.lifecycle.Events()
+}
+
+// GetTpuConfigs is GetTpuConfigsContext bound to the client lifetime.
 func (c *Client) GetTpuConfigs(opts ...grpc.CallOption) (*jito_pb.GetTpuConfigsResponse, error) {
-	return c.Relayer.GetTpuConfigs(c.Auth.GrpcCtx, &jito_pb.GetTpuConfigsRequest{}, opts...)
+	return c.GetTpuConfigsContext(c.lifecycle.Context(), opts...)
+}
+
+// GetTpuConfigsContext returns the TPU and TPU forward sockets of the relayer.
+func (c *Client) GetTpuConfigsContext(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.GetTpuConfigsResponse, error) {
+	return c.Relayer.GetTpuConfigs(c.Auth.AuthorizeContext(ctx), &jito_pb.GetTpuConfigsRequest{}, opts...)
+}
+
+// NewTPUSender fetches the TPU sockets of the relayer and opens a pkg.TPUSender to send transactions to them.
+// QUIC connections present the client keypair unless config.PrivateKey is set. The sender is closed along with the client.
+func (c *Client) NewTPUSender(ctx context.Context, config pkg.TPUSenderConfig) (*TPUSender, error) {
+	resp, err := c.GetTpuConfigsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tpu configs: %w", err)
+	}
+
+	if config.PrivateKey == nil && c.Auth.KeyPair != nil {
+		config.PrivateKey = c.Auth.KeyPair.PrivateKey
+	}
+
+	sender, err := pkg.NewTPUSender(config)
+	if err != nil {
+		return nil, err
+	}
+	c.lifecycle.OnClose(sender.Close)
+
+	return &TPUSender{TPUSender: sender, Tpu: resp.GetTpu(), TpuForward: resp.GetTpuForward()}, nil
 }
 
+// SendToTpu sends txns to the TPU socket of the relayer.
+func (s *TPUSender) SendToTpu(ctx context.Context, txns ...*solana.Transaction) error {
+	return s.SendSocket(ctx, s.Tpu, txns...)
+}
+
+// SendToTpuForward sends txns to the TPU forward socket of the relayer.
+func (s *TPUSender) SendToTpuForward(ctx context.Context, txns ...*solana.Transaction) error {
+	return s.SendSocket(ctx, s.TpuForward, txns...)
+}
+
+// NewPacketsSubscription is NewPacketsSubscriptionContext bound to the client lifetime.
 func (c *Client) NewPacketsSubscription(opts ...grpc.CallOption) (jito_pb.Relayer_SubscribePacketsClient, error) {
-	return c.Relayer.SubscribePackets(c.Auth.GrpcCtx, &jito_pb.SubscribePacketsRequest{}, opts...)
+	return c.NewPacketsSubscriptionContext(c.lifecycle.Context(), opts...)
+}
+
+// NewPacketsSubscriptionContext opens the packet stream, it ends when ctx is done.
+func (c *Client) NewPacketsSubscriptionContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.Relayer_SubscribePacketsClient, error) {
+	return c.Relayer.SubscribePackets(c.Auth.AuthorizeContext(ctx), &jito_pb.SubscribePacketsRequest{}, opts...)
 }
 
-// SubscribePackets is a wrapper around NewPacketsSubscription.
-func (c *Client) SubscribePackets(ctx context.Context) (<-chan []*solana.Transaction, <-chan error, error) {
-	chTx := make(chan []*solana.Transaction)
-	chErr := make(chan error)
+// SubscribePackets is a wrapper around NewPacketsSubscription yielding every batch decoded, along with the metadata of
+// each packet. Packets rejected by one of filters, e.g. pkg.SkipVotes(), are skipped before being decoded and packets
+// which could not be decoded are reported in the Errors of their batch, the rest of the batch is kept.
+//
+// The relayer sends a heartbeat every HeartbeatInterval, after MaxMissedHeartbeats missed in a row the stream is
+// reported as stalled and re-opened, like it is whenever it fails; pkg.WithHeartbeatTimeout overrides this.
+// Stream errors, pkg.ErrStreamStalled included, are sent on the error channel and dropped when it is full.
+func (c *Client) SubscribePackets(ctx context.Context, filters []pkg.PacketFilter, opts ...pkg.StreamOption) (<-chan PacketBatch, <-chan error, error) {
+	ctx, cancel := c.lifecycle.Bind(ctx)
 
-	sub, err := c.NewPacketsSubscription()
+	streamOpts := []pkg.StreamOption{pkg.WithHeartbeatTimeout(HeartbeatInterval * MaxMissedHeartbeats)}
+	streamOpts = append(streamOpts, c.streamOpts...)
+	streamOpts = append(streamOpts, pkg.WithEventBus(c.lifecycle.Events(), "SubscribePackets"))
+	stream, err := pkg.NewResilientStream(ctx, func(ctx context.Context) (pkg.Receiver[*jito_pb.SubscribePacketsResponse], error) {
+		return c.NewPacketsSubscriptionContext(ctx)
+	}, append(streamOpts, opts...)...)
 	if err != nil {
+		cancel()
 		return nil, nil, err
 	}
 
-	go func() {
+	chBatch := make(chan PacketBatch)
+	chErr := make(chan error, 16)
+
+	c.lifecycle.Go(func(context.Context) {
+		defer cancel()
+		defer close(chBatch)
+		defer close(chErr)
+
+		streamErrs := stream.Errors()
 		for {
 			select {
-			case <-ctx.Done():
-				return
-			case <-c.Auth.GrpcCtx.Done():
-				return
-			default:
-				recv, err := sub.Recv()
-				if err != nil {
-					chErr <- fmt.Errorf("SubscribePackets: failed to receive packet information: %w", err)
+			case err, ok := <-streamErrs:
+				if !ok {
+					streamErrs = nil
+					continue
+				}
+				pkg.DispatchError(chErr, fmt.Errorf("SubscribePackets: %w", err))
+			case recv, ok := <-stream.Data():
+				if !ok {
+					return
+				}
+
+				// heartbeats only keep the stream from being reported as stalled
+				if recv.GetBatch() == nil {
+					continue
 				}
 
-				packets := recv.GetBatch().GetPackets()
-				var txns = make([]*solana.Transaction, 0, len(packets))
-				txns, err = pkg.ConvertBatchProtobufPacketToTransaction(packets)
-				if err != nil {
-					chErr <- fmt.Errorf("SubscribePackets: failed to convert protobuf packet to transaction: %w", err)
+				packets, errs := pkg.DecodePackets(recv.GetHeader(), recv.GetBatch().GetPackets(), filters...)
+				if len(packets) == 0 && len(errs) == 0 {
+					continue
 				}
 
-				chTx <- txns
+				select {
+				case chBatch <- PacketBatch{Packets: packets, Errors: errs}:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
-	}()
+	})
 
-	return chTx, chErr, nil
+	return chBatch, chErr, nil
+}
+
+// Transactions returns the transactions of the batch in order.
+func (b PacketBatch) Transactions() []*solana.Transaction {
+	txns := make([]*solana.Transaction, len(b.Packets))
+	for i, packet := range b.Packets {
+		txns[i] = packet.Transaction
+	}
+	return txns
 }
@@ -2,13 +2,20 @@ package relayer_client
 
 import (
 	"context"
+	"errors"
 	"github.com/gagliardetto/solana-go"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"github.com/weeaa/jito-go/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -61,3 +68,160 @@ func Test_RelayerClient(t *testing.T) {
 		recv.Header.String()
 	})
 }
+
+type fakeRelayer struct {
+	jito_pb.UnimplementedRelayerServer
+	tpu *jito_pb.Socket
+
+	// streams holds the responses of every packet subscription in order, a subscription goes silent once they are sent.
+	streams     [][]*jito_pb.SubscribePacketsResponse
+	subscribers atomic.Int32
+}
+
+func (s *fakeRelayer) SubscribePackets(_ *jito_pb.SubscribePacketsRequest, stream jito_pb.Relayer_SubscribePacketsServer) error {
+	i := int(s.subscribers.Add(1)) - 1
+	if i < len(s.streams) {
+		for _, resp := range s.streams[i] {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+
+	<-stream.Context().Done()
+	return nil
+}
+
+func (s *fakeRelayer) GetTpuConfigs(context.Context, *jito_pb.GetTpuConfigsRequest) (*jito_pb.GetTpuConfigsResponse, error) {
+	return &jito_pb.GetTpuConfigsResponse{Tpu: s.tpu, TpuForward: s.tpu}, nil
+}
+
+func newFakeClient(t *testing.T, ctx context.Context, server *fakeRelayer) *Client {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	jito_pb.RegisterRelayerServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	client := &Client{GrpcConn: conn, Relayer: jito_pb.NewRelayerClient(conn), Auth: &pkg.AuthenticationService{}, lifecycle: pkg.NewLifecycle(ctx, 0)}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestTPUSender(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	port := listener.LocalAddr().(*net.UDPAddr).Port
+	client := newFakeClient(t, ctx, &fakeRelayer{tpu: &jito_pb.Socket{Ip: "127.0.0.1", Port: int64(port)}})
+
+	sender, err := client.NewTPUSender(ctx, pkg.TPUSenderConfig{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	payer := solana.NewWallet()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).WRITE().SIGNER()}, []byte{2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}),
+	}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	payload, _ := tx.MarshalBinary()
+
+	assert.NoError(t, sender.SendToTpu(ctx, tx))
+	assert.NoError(t, sender.SendToTpuForward(ctx, tx))
+
+	buf := make([]byte, 2048)
+	for i := 0; i < 2; i++ {
+		listener.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := listener.ReadFromUDP(buf)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, payload, buf[:n])
+	}
+
+	assert.Equal(t, uint64(2), sender.Stats()[listener.LocalAddr().String()].Sent)
+
+	// the sender is closed along with the client
+	client.Close()
+	assert.ErrorIs(t, sender.SendToTpu(ctx, tx), pkg.ErrTPUSenderClosed)
+}
+
+func TestSubscribePackets(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	payer := solana.NewWallet()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{solana.Meta(payer.PublicKey()).WRITE().SIGNER()}, []byte{2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}),
+	}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	packet, err := pkg.ConvertTransactionToProtobufPacket(tx)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	packet.Meta = &jito_pb.Meta{Addr: "1.2.3.4", Port: 8001, SenderStake: 42}
+
+	heartbeat := &jito_pb.SubscribePacketsResponse{Msg: &jito_pb.SubscribePacketsResponse_Heartbeat{Heartbeat: &jito_pb.Heartbeat{}}}
+	batch := func(packets ...*jito_pb.Packet) *jito_pb.SubscribePacketsResponse {
+		return &jito_pb.SubscribePacketsResponse{Msg: &jito_pb.SubscribePacketsResponse_Batch{Batch: &jito_pb.PacketBatch{Packets: packets}}}
+	}
+
+	server := &fakeRelayer{streams: [][]*jito_pb.SubscribePacketsResponse{
+		// the first subscription sends a batch with an invalid packet then stops sending heartbeats
+		{heartbeat, batch(&packet, &jito_pb.Packet{Data: []byte{1, 2, 3}}), heartbeat},
+		{heartbeat, batch(&packet)},
+	}}
+	client := newFakeClient(t, ctx, server)
+
+	batches, errs, err := client.SubscribePackets(ctx, nil,
+		pkg.WithHeartbeatTimeout(200*time.Millisecond),
+		pkg.WithBackoff(pkg.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2}),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	first := <-batches
+	if assert.Len(t, first.Packets, 1) && assert.Len(t, first.Errors, 1) {
+		assert.Equal(t, tx.Message.AccountKeys, first.Transactions()[0].Message.AccountKeys)
+		assert.Equal(t, uint64(42), first.Packets[0].Meta.SenderStake)
+		assert.Equal(t, "1.2.3.4", first.Packets[0].Meta.Addr)
+
+		var decodeErr *pkg.PacketDecodeError
+		if assert.ErrorAs(t, first.Errors[0], &decodeErr) {
+			assert.Equal(t, 1, decodeErr.Index)
+		}
+	}
+
+	select {
+	case err = <-errs:
+		assert.True(t, errors.Is(err, pkg.ErrStreamStalled))
+	case <-ctx.Done():
+		t.Fatal("stall not reported")
+	}
+
+	second := <-batches
+	assert.Len(t, second.Packets, 1)
+	assert.Empty(t, second.Errors)
+	assert.Equal(t, int32(2), server.subscribers.Load())
+}
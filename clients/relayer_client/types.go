@@ -4,14 +4,45 @@ import (
 	"github.com/weeaa/jito-go/pb"
 	"github.com/weeaa/jito-go/pkg"
 	"google.golang.org/grpc"
+	"time"
+)
+
+const (
+	// HeartbeatInterval is how often the relayer sends a heartbeat on the packet stream.
+	HeartbeatInterval = 500 * time.Millisecond
+	// MaxMissedHeartbeats is the amount of heartbeats missed in a row after which the packet stream is re-opened.
+	MaxMissedHeartbeats = 3
 )
 
 type Client struct {
-	GrpcConn *grpc.ClientConn
+	// GrpcConn is the connection dialed by the constructor, it is closed once the Supervisor recreates it.
+	//
+	// Deprecated: use Supervisor.Conn, which returns the live connection.
+	GrpcConn   *grpc.ClientConn
+	Supervisor *pkg.ConnSupervisor // Owns the connection and recreates it when it dies.
 
 	Relayer jito_pb.RelayerClient
 
 	Auth *pkg.AuthenticationService
 
-	ErrChan chan error // ErrChan is used for dispatching errors from functions executed within goroutines.
+	// ErrChan is used for dispatching errors from functions executed within goroutines, errors are dropped when it is full.
+	//
+	// Deprecated: subscribe to pkg.ErrorEvent on Events instead.
+	ErrChan chan error
+
+	streamOpts []pkg.StreamOption // defaults applied to every subscription.
+	lifecycle  *pkg.Lifecycle
+}
+
+// TPUSender sends transactions to the TPU sockets advertised by the relayer.
+type TPUSender struct {
+	*pkg.TPUSender
+	Tpu        *jito_pb.Socket
+	TpuForward *jito_pb.Socket
+}
+
+// PacketBatch is a batch of packets forwarded by the relayer.
+type PacketBatch struct {
+	Packets []pkg.Packet
+	Errors  []error // *pkg.PacketDecodeError of the packets which could not be decoded.
 }
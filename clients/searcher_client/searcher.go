@@ -1,78 +1,119 @@
 package searcher_client
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/weeaa/jito-go"
 	"github.com/weeaa/jito-go/pb"
 	"github.com/weeaa/jito-go/pkg"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"io"
 	"math/rand"
-	"net"
 	"net/http"
 	"net/url"
-	"strings"
+	"slices"
 	"sync"
 	"time"
 )
 
-// New creates a new Searcher Client instance.
-func New(
-	ctx context.Context,
-	blockEngineURL string,
-	jitoRpcClient, rpcClient *rpc.Client,
-	privateKey solana.PrivateKey,
-	tlsConfig *tls.Config,
-	opts ...grpc.DialOption,
-) (*Client, error) {
-	if tlsConfig != nil {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
-	}
+// NewWithOptions creates a new Searcher Client from pkg.ClientOption values, e.g.
+//
+//	searcher_client.NewWithOptions(ctx, pkg.WithEndpoint(jito_go.NewYork.BlockEngineURL), pkg.WithPrivateKey(key), pkg.WithRPCClients(rpcClient, jitoRpcClient))
+//
+// The client authenticates with the private key unless pkg.WithNoAuth is set or no private key is provided.
+func NewWithOptions(ctx context.Context, opts ...pkg.ClientOption) (*Client, error) {
+	o := pkg.NewClientOptions(opts...)
 
-	chErr := make(chan error)
-	conn, err := pkg.CreateAndObserveGRPCConn(ctx, chErr, blockEngineURL, opts...)
+	endpoint, err := o.ResolveEndpoint(ctx, func(info jito_go.JitoEndpointInfo) string { return info.BlockEngineURL })
 	if err != nil {
 		return nil, err
 	}
 
-	searcherService := jito_pb.NewSearcherServiceClient(conn)
-	authService := pkg.NewAuthenticationService(conn, privateKey)
-	if err = authService.AuthenticateAndRefresh(jito_pb.Role_SEARCHER); err != nil {
+	lifecycle := pkg.NewLifecycle(ctx, 16)
+	supervisor, err := o.Dial(lifecycle, endpoint)
+	if err != nil {
+		lifecycle.Close()
 		return nil, err
 	}
 
-	subBundleRes, err := searcherService.SubscribeBundleResults(authService.GrpcCtx, &jito_pb.SubscribeBundleResultsRequest{})
+	searcherService := jito_pb.NewSearcherServiceClient(supervisor)
+	authService := &pkg.AuthenticationService{GrpcCtx: lifecycle.Context()}
+	if !o.NoAuth && o.PrivateKey != nil {
+		authService = pkg.NewAuthenticationService(supervisor, o.PrivateKey)
+		authService.Events = lifecycle.Events()
+		lifecycle.OnClose(authService.Close)
+		if err = authService.AuthenticateAndRefreshContext(lifecycle.Context(), jito_pb.Role_SEARCHER); err != nil {
+			lifecycle.Close()
+			return nil, err
+		}
+	}
+
+	// bundle results are consumed once here and fanned out on the event bus, so any number of callers can wait for theirs
+	bundleResults, err := pkg.NewResilientStream(lifecycle.Context(), func(ctx context.Context) (pkg.Receiver[*jito_pb.BundleResult], error) {
+		return searcherService.SubscribeBundleResults(authService.AuthorizeContext(ctx), &jito_pb.SubscribeBundleResultsRequest{})
+	}, append(o.StreamOptions(), pkg.WithEventBus(lifecycle.Events(), "SubscribeBundleResults"))...)
 	if err != nil {
+		lifecycle.Close()
 		return nil, err
 	}
+	lifecycle.Go(func(context.Context) {
+		for result := range bundleResults.Data() {
+			lifecycle.Events().Publish(pkg.BundleResultEvent{Result: result, Time: time.Now()})
+		}
+	})
 
-	client := Client{
-		GrpcConn:                 conn,
-		RpcConn:                  rpcClient,
-		JitoRpcConn:              jitoRpcClient,
-		SearcherService:          searcherService,
-		BundleStreamSubscription: subBundleRes,
-		Auth:                     authService,
-		ErrChan:                  chErr,
+	client := &Client{
+		GrpcConn:        supervisor.Conn(),
+		Supervisor:      supervisor,
+		RpcConn:         o.RpcClient,
+		JitoRpcConn:     o.JitoRpcClient,
+		SearcherService: searcherService,
+		Auth:            authService,
+		ErrChan:         lifecycle.Errors(),
+		lifecycle:       lifecycle,
+		logger:          o.Logger,
+		dialOpts:        o.BaseDialOptions(),
+	}
+	client.BundleStreamSubscription = &lazyBundleStream{
+		ctx: lifecycle.Context(),
+		open: func(ctx context.Context) (jito_pb.SearcherService_SubscribeBundleResultsClient, error) {
+			return searcherService.SubscribeBundleResults(authService.AuthorizeContext(ctx), &jito_pb.SubscribeBundleResultsRequest{})
+		},
 	}
+	supervisor.OnReconnect(client.rebind)
 
-	return &client, nil
+	return client, nil
+}
+
+// New creates a new Searcher Client instance, use NewWithOptions and pkg.WithProxy to connect through a proxy.
+func New(
+	ctx context.Context,
+	blockEngineURL string,
+	jitoRpcClient, rpcClient *rpc.Client,
+	privateKey solana.PrivateKey,
+	tlsConfig *tls.Config,
+	opts ...grpc.DialOption,
+) (*Client, error) {
+	return NewWithOptions(ctx,
+		pkg.WithEndpoint(blockEngineURL),
+		pkg.WithRPCClients(rpcClient, jitoRpcClient),
+		pkg.WithPrivateKey(privateKey),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
 }
 
 // NewNoAuth initializes and returns a new instance of the Searcher Client which does not require private key signing.
-// Proxy feature allows you to have different Jito clients running on the same machine without hitting rate limits due to IP limits.
+// Proxy feature allows you to have different Jito clients running on the same machine without hitting rate limits due to IP limits,
+// proxyURL is optional and supports the http://, https:// and socks5:// schemes.
 func NewNoAuth(ctx context.Context,
 	blockEngineURL string,
 	jitoRpcClient, rpcClient *rpc.Client,
@@ -80,186 +121,239 @@ func NewNoAuth(ctx context.Context,
 	tlsConfig *tls.Config,
 	opts ...grpc.DialOption,
 ) (*Client, error) {
-	if tlsConfig != nil {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
-	}
+	return NewWithOptions(ctx,
+		pkg.WithEndpoint(blockEngineURL),
+		pkg.WithRPCClients(rpcClient, jitoRpcClient),
+		pkg.WithNoAuth(),
+		pkg.WithProxy(proxyURL),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
+}
 
-	if proxyURL != "" {
-		dialer, err := createContextDialer(proxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create proxy dialer: %w", err)
-		}
+// NewFastestRegion creates a client connected to the fastest block engine of ranker, ranking the regions first if
+// they were never probed. The other arguments are the same as New.
+func NewFastestRegion(
+	ctx context.Context,
+	ranker *pkg.RegionRanker,
+	jitoRpcClient, rpcClient *rpc.Client,
+	privateKey solana.PrivateKey,
+	tlsConfig *tls.Config,
+	opts ...grpc.DialOption,
+) (*Client, error) {
+	return NewWithOptions(ctx,
+		pkg.WithFastestRegion(ranker),
+		pkg.WithRPCClients(rpcClient, jitoRpcClient),
+		pkg.WithPrivateKey(privateKey),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
+}
 
-		opts = append(opts,
-			grpc.WithContextDialer(dialer),
-			defaultKeepAlive,
-		)
+// NewFastestRegions creates one client per region for the n fastest block engines of ranker, fastest first.
+// Fewer clients are returned when less than n regions are reachable. pkg.WithEndpoint is ignored.
+func NewFastestRegions(ctx context.Context, ranker *pkg.RegionRanker, n int, opts ...pkg.ClientOption) ([]*Client, error) {
+	if ranker.Ranking() == nil {
+		ranker.Rank(ctx)
 	}
 
-	chErr := make(chan error)
-	conn, err := pkg.CreateAndObserveGRPCConn(ctx, chErr, blockEngineURL, opts...)
+	endpoints, err := ranker.FastestN(n)
 	if err != nil {
 		return nil, err
 	}
 
-	searcherService := jito_pb.NewSearcherServiceClient(conn)
-	subBundleRes, err := searcherService.SubscribeBundleResults(ctx, &jito_pb.SubscribeBundleResultsRequest{})
-	if err != nil {
-		return nil, err
+	clients := make([]*Client, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		client, err := NewWithOptions(ctx, append(slices.Clone(opts), pkg.WithEndpoint(endpoint.BlockEngineURL))...)
+		if err != nil {
+			closeClients(clients)
+			return nil, fmt.Errorf("failed to connect to %s: %w", endpoint.BlockEngineURL, err)
+		}
+		clients = append(clients, client)
 	}
 
-	client := Client{
-		GrpcConn:                 conn,
-		RpcConn:                  rpcClient,
-		JitoRpcConn:              jitoRpcClient,
-		SearcherService:          searcherService,
-		BundleStreamSubscription: subBundleRes,
-		Auth:                     &pkg.AuthenticationService{GrpcCtx: ctx},
-		ErrChan:                  chErr,
+	return clients, nil
+}
+
+// NewHedged creates a HedgedClient with one client per region, regions being keys of jito_go.JitoEndpoints tried in order.
+// The opts are shared by every client, pkg.WithEndpoint is ignored.
+func NewHedged(ctx context.Context, regions []string, delay time.Duration, opts ...pkg.ClientOption) (*HedgedClient, error) {
+	hedged := &HedgedClient{Delay: delay}
+	for _, region := range regions {
+		endpoint, ok := jito_go.JitoEndpoints[region]
+		if !ok {
+			closeClients(hedged.Clients)
+			return nil, fmt.Errorf("unknown region %s", region)
+		}
+
+		client, err := NewWithOptions(ctx, append(slices.Clone(opts), pkg.WithEndpoint(endpoint.BlockEngineURL))...)
+		if err != nil {
+			closeClients(hedged.Clients)
+			return nil, fmt.Errorf("failed to connect to %s: %w", endpoint.BlockEngineURL, err)
+		}
+		hedged.Clients = append(hedged.Clients, client)
 	}
 
-	return &client, nil
+	return hedged, nil
 }
 
-// RotateProxy updates the client's gRPC connection to use a new proxy URL. This allows dynamic rotation of proxies to avoid rate limits.
-func RotateProxy(client *Client, proxyURL string) error {
-	blockEngineURL := client.GrpcConn.Target()
+// Close closes every client.
+func (h *HedgedClient) Close() error {
+	var errs []error
+	for _, c := range h.Clients {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
 
-	dialer, err := createContextDialer(proxyURL)
-	if err != nil {
-		return fmt.Errorf("failed to create new proxy dialer: %w", err)
+func hedge[T any](ctx context.Context, h *HedgedClient, call func(ctx context.Context, c *Client) (T, error)) (T, error) {
+	calls := make([]func(context.Context) (T, error), len(h.Clients))
+	for i, c := range h.Clients {
+		calls[i] = func(ctx context.Context) (T, error) {
+			return call(ctx, c)
+		}
 	}
+	return pkg.Hedge(ctx, h.Delay, calls...)
+}
 
-	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+// GetTipAccounts returns the Jito Tip Accounts of the first region answering.
+func (h *HedgedClient) GetTipAccounts(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.GetTipAccountsResponse, error) {
+	return hedge(ctx, h, func(ctx context.Context, c *Client) (*jito_pb.GetTipAccountsResponse, error) {
+		return c.GetTipAccountsContext(ctx, opts...)
+	})
+}
 
-	opts = append(opts,
-		grpc.WithContextDialer(dialer),
-		defaultKeepAlive,
-	)
+// GetNextScheduledLeader returns the next scheduled leader seen by the first region answering.
+func (h *HedgedClient) GetNextScheduledLeader(ctx context.Context, regions []string, opts ...grpc.CallOption) (*jito_pb.NextScheduledLeaderResponse, error) {
+	return hedge(ctx, h, func(ctx context.Context, c *Client) (*jito_pb.NextScheduledLeaderResponse, error) {
+		return c.GetNextScheduledLeaderContext(ctx, regions, opts...)
+	})
+}
 
-	if err := client.GrpcConn.Close(); err != nil {
-		return fmt.Errorf("failed to close existing connection: %w", err)
-	}
+// GetConnectedLeaders returns the connected leaders seen by the first region answering.
+func (h *HedgedClient) GetConnectedLeaders(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersResponse, error) {
+	return hedge(ctx, h, func(ctx context.Context, c *Client) (*jito_pb.ConnectedLeadersResponse, error) {
+		return c.GetConnectedLeadersContext(ctx, opts...)
+	})
+}
 
-	chErr := make(chan error)
-	ctx := client.Auth.GrpcCtx
-	conn, err := pkg.CreateAndObserveGRPCConn(ctx, chErr, blockEngineURL, opts...)
+// RotateProxy updates the client's gRPC connection to use a new proxy URL. This allows dynamic rotation of proxies to avoid rate limits.
+// The TLS configuration, the authentication and the bundle results subscription are kept.
+func RotateProxy(client *Client, proxyURL string) error {
+	proxyOpt, err := pkg.ProxyDialOption(proxyURL)
 	if err != nil {
-		return fmt.Errorf("failed to create new connection: %w", err)
+		return fmt.Errorf("failed to create new proxy dialer: %w", err)
 	}
 
-	searcherService := jito_pb.NewSearcherServiceClient(conn)
-	subBundleRes, err := searcherService.SubscribeBundleResults(ctx, &jito_pb.SubscribeBundleResultsRequest{})
-	if err != nil {
-		return fmt.Errorf("failed to resubscribe to bundle results: %w", err)
+	client.Supervisor.SetDialOptions(append(slices.Clone(client.dialOpts), proxyOpt, defaultKeepAlive)...)
+	if err = client.Supervisor.Reconnect(); err != nil {
+		return fmt.Errorf("failed to create new connection: %w", err)
 	}
 
-	client.GrpcConn = conn
-	client.SearcherService = searcherService
-	client.BundleStreamSubscription = subBundleRes
-	client.ErrChan = chErr
-
 	return nil
 }
 
-func parseProxyString(proxyStr string) (host string, port string, username string, password string, err error) {
-	parts := strings.Split(proxyStr, ":")
-	if len(parts) != 4 {
-		return "", "", "", "", fmt.Errorf("invalid proxy format, expected IP:PORT:USERNAME:PASSWORD")
-	}
-	return parts[0], parts[1], parts[2], parts[3], nil
+// UseProxyPool routes the client's gRPC connection through pool. Calls are reported to the pool and the connection
+// is re-dialed through another proxy once its proxy fails or is rate limited, see pkg.ProxyPool.DialOptions.
+func (c *Client) UseProxyPool(pool *pkg.ProxyPool) error {
+	opts := append(slices.Clone(c.dialOpts), pool.DialOptions(pkg.ReconnectFunc(c.lifecycle, func() *pkg.ConnSupervisor { return c.Supervisor }))...)
+	c.Supervisor.SetDialOptions(append(opts, defaultKeepAlive)...)
+	return c.Supervisor.Reconnect()
 }
 
-type proxyDialer struct {
-	proxyHost string
-	auth      string
-	timeout   time.Duration
+// rebind is called by the Supervisor once the gRPC connection has been recreated, the service stub goes through the
+// Supervisor and streams re-open on their own.
+func (c *Client) rebind(conn *grpc.ClientConn) {
+	c.logger.Debug("searcher connection rebound", "target", conn.Target())
 }
 
-func newProxyDialer(proxyStr string) (*proxyDialer, error) {
-	host, port, username, password, err := parseProxyString(proxyStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid proxy string: %w", err)
-	}
-
-	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
-
-	return &proxyDialer{
-		proxyHost: net.JoinHostPort(host, port),
-		auth:      auth,
-		timeout:   30 * time.Second,
-	}, nil
+func (s *lazyBundleStream) get() (jito_pb.SearcherService_SubscribeBundleResultsClient, error) {
+	s.once.Do(func() {
+		s.stream, s.err = s.open(s.ctx)
+	})
+	return s.stream, s.err
 }
 
-func (d *proxyDialer) dialProxy(ctx context.Context, addr string) (net.Conn, error) {
-	var conn net.Conn
-	var err error
-
-	dialer := &net.Dialer{
-		Timeout:   d.timeout,
-		KeepAlive: 30 * time.Second,
+func (s *lazyBundleStream) Recv() (*jito_pb.BundleResult, error) {
+	stream, err := s.get()
+	if err != nil {
+		return nil, err
 	}
+	return stream.Recv()
+}
 
-	conn, err = dialer.DialContext(ctx, "tcp", d.proxyHost)
+func (s *lazyBundleStream) Header() (metadata.MD, error) {
+	stream, err := s.get()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to proxy %s: %w", d.proxyHost, err)
+		return nil, err
 	}
+	return stream.Header()
+}
 
-	connectReq := fmt.Sprintf(
-		"CONNECT %s HTTP/1.1\r\n"+
-			"Host: %s\r\n"+
-			"Proxy-Authorization: Basic %s\r\n"+
-			"User-Agent: jito-go/1.1\r\n"+
-			"\r\n",
-		addr, addr, d.auth,
-	)
-
-	if _, err = conn.Write([]byte(connectReq)); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to write CONNECT request: %w", err)
+func (s *lazyBundleStream) Trailer() metadata.MD {
+	if stream, err := s.get(); err == nil {
+		return stream.Trailer()
 	}
+	return nil
+}
 
-	br := bufio.NewReader(conn)
-	resp, err := http.ReadResponse(br, &http.Request{Method: "CONNECT"})
+func (s *lazyBundleStream) CloseSend() error {
+	stream, err := s.get()
 	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to read CONNECT response: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	return stream.CloseSend()
+}
 
-	if resp.StatusCode != 200 {
-		conn.Close()
-		return nil, fmt.Errorf("proxy connection failed: %s", resp.Status)
+func (s *lazyBundleStream) Context() context.Context {
+	if stream, err := s.get(); err == nil {
+		return stream.Context()
 	}
+	return s.ctx
+}
 
-	return conn, nil
+func (s *lazyBundleStream) SendMsg(m any) error {
+	stream, err := s.get()
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(m)
 }
 
-func createContextDialer(proxyStr string) (func(context.Context, string) (net.Conn, error), error) {
-	pd, err := newProxyDialer(proxyStr)
+func (s *lazyBundleStream) RecvMsg(m any) error {
+	stream, err := s.get()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return stream.RecvMsg(m)
+}
 
-	return pd.dialProxy, nil
+// Events returns the event bus of the client: connection state changes, reconnections, token refreshes,
+// stream events, dropped messages, bundle results and errors. Subscribe with pkg.Subscribe.
+func (c *Client) Events() *pkg.EventBus {
+	return c.lifecycle.Events()
 }
 
+// Close stops every goroutine of the client, waits for them and closes the connection and the rpc clients.
+// It can be called several times.
 func (c *Client) Close() error {
-	close(c.ErrChan)
-	defer c.Auth.GrpcCtx.Done()
-
-	if err := c.RpcConn.Close(); err != nil {
-		return err
+	// the rpc clients are released last, once nothing can use them anymore
+	errs := []error{c.lifecycle.Close()}
+	if c.RpcConn != nil {
+		errs = append(errs, c.RpcConn.Close())
 	}
-
-	if err := c.JitoRpcConn.Close(); err != nil {
-		return err
+	if c.JitoRpcConn != nil {
+		errs = append(errs, c.JitoRpcConn.Close())
 	}
+	return errors.Join(errs...)
+}
 
-	return c.GrpcConn.Close()
+// closeClients closes the clients created by a constructor which failed afterward, the rpc clients are shared by
+// every client and left open.
+func closeClients(clients []*Client) {
+	for _, c := range clients {
+		c.lifecycle.Close()
+	}
 }
 
 /*
@@ -381,26 +475,54 @@ func (c *Client) SubscribeProgramsMempoolTransactions(ctx context.Context, progr
 }
 */
 
+// GetRegions is GetRegionsContext bound to the client lifetime.
 func (c *Client) GetRegions(opts ...grpc.CallOption) (*jito_pb.GetRegionsResponse, error) {
-	return c.SearcherService.GetRegions(c.Auth.GrpcCtx, &jito_pb.GetRegionsRequest{}, opts...)
+	return c.GetRegionsContext(c.lifecycle.Context(), opts...)
 }
 
+// GetRegionsContext returns the region the client is connected to and the regions available to connect to.
+func (c *Client) GetRegionsContext(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.GetRegionsResponse, error) {
+	return c.SearcherService.GetRegions(c.Auth.AuthorizeContext(ctx), &jito_pb.GetRegionsRequest{}, opts...)
+}
+
+// GetConnectedLeaders is GetConnectedLeadersContext bound to the client lifetime.
 func (c *Client) GetConnectedLeaders(opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersResponse, error) {
-	return c.SearcherService.GetConnectedLeaders(c.Auth.GrpcCtx, &jito_pb.ConnectedLeadersRequest{}, opts...)
+	return c.GetConnectedLeadersContext(c.lifecycle.Context(), opts...)
+}
+
+// GetConnectedLeadersContext returns the validators connected to the block engine, by identity.
+func (c *Client) GetConnectedLeadersContext(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersResponse, error) {
+	return c.SearcherService.GetConnectedLeaders(c.Auth.AuthorizeContext(ctx), &jito_pb.ConnectedLeadersRequest{}, opts...)
 }
 
+// GetConnectedLeadersRegioned is GetConnectedLeadersRegionedContext bound to the client lifetime.
 func (c *Client) GetConnectedLeadersRegioned(regions []string, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersRegionedResponse, error) {
-	return c.SearcherService.GetConnectedLeadersRegioned(c.Auth.GrpcCtx, &jito_pb.ConnectedLeadersRegionedRequest{Regions: regions}, opts...)
+	return c.GetConnectedLeadersRegionedContext(c.lifecycle.Context(), regions, opts...)
 }
 
-// GetTipAccounts returns Jito Tip Accounts.
+// GetConnectedLeadersRegionedContext returns the validators connected to the block engines of regions, by region.
+func (c *Client) GetConnectedLeadersRegionedContext(ctx context.Context, regions []string, opts ...grpc.CallOption) (*jito_pb.ConnectedLeadersRegionedResponse, error) {
+	return c.SearcherService.GetConnectedLeadersRegioned(c.Auth.AuthorizeContext(ctx), &jito_pb.ConnectedLeadersRegionedRequest{Regions: regions}, opts...)
+}
+
+// GetTipAccounts is GetTipAccountsContext bound to the client lifetime.
 func (c *Client) GetTipAccounts(opts ...grpc.CallOption) (*jito_pb.GetTipAccountsResponse, error) {
-	return c.SearcherService.GetTipAccounts(c.Auth.GrpcCtx, &jito_pb.GetTipAccountsRequest{}, opts...)
+	return c.GetTipAccountsContext(c.lifecycle.Context(), opts...)
+}
+
+// GetTipAccountsContext returns Jito Tip Accounts.
+func (c *Client) GetTipAccountsContext(ctx context.Context, opts ...grpc.CallOption) (*jito_pb.GetTipAccountsResponse, error) {
+	return c.SearcherService.GetTipAccounts(c.Auth.AuthorizeContext(ctx), &jito_pb.GetTipAccountsRequest{}, opts...)
 }
 
-// GetRandomTipAccount returns a random Jito TipAccount.
+// GetRandomTipAccount is GetRandomTipAccountContext bound to the client lifetime.
 func (c *Client) GetRandomTipAccount(opts ...grpc.CallOption) (string, error) {
-	resp, err := c.GetTipAccounts(opts...)
+	return c.GetRandomTipAccountContext(c.lifecycle.Context(), opts...)
+}
+
+// GetRandomTipAccountContext returns a random Jito TipAccount.
+func (c *Client) GetRandomTipAccountContext(ctx context.Context, opts ...grpc.CallOption) (string, error) {
+	resp, err := c.GetTipAccountsContext(ctx, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -408,48 +530,78 @@ func (c *Client) GetRandomTipAccount(opts ...grpc.CallOption) (string, error) {
 	return resp.Accounts[rand.Intn(len(resp.Accounts))], nil
 }
 
+// GetNextScheduledLeader is GetNextScheduledLeaderContext bound to the client lifetime.
 func (c *Client) GetNextScheduledLeader(regions []string, opts ...grpc.CallOption) (*jito_pb.NextScheduledLeaderResponse, error) {
-	return c.SearcherService.GetNextScheduledLeader(c.Auth.GrpcCtx, &jito_pb.NextScheduledLeaderRequest{Regions: regions}, opts...)
+	return c.GetNextScheduledLeaderContext(c.lifecycle.Context(), regions, opts...)
+}
+
+// GetNextScheduledLeaderContext returns the next leader connected to the block engines of regions, and the current slot.
+func (c *Client) GetNextScheduledLeaderContext(ctx context.Context, regions []string, opts ...grpc.CallOption) (*jito_pb.NextScheduledLeaderResponse, error) {
+	return c.SearcherService.GetNextScheduledLeader(c.Auth.AuthorizeContext(ctx), &jito_pb.NextScheduledLeaderRequest{Regions: regions}, opts...)
 }
 
-// NewBundleSubscriptionResults creates a new bundle subscription stream, allowing to receive information about broadcasted bundles.
+// NewBundleSubscriptionResults is NewBundleSubscriptionResultsContext bound to the client lifetime.
 func (c *Client) NewBundleSubscriptionResults(opts ...grpc.CallOption) (jito_pb.SearcherService_SubscribeBundleResultsClient, error) {
-	return c.SearcherService.SubscribeBundleResults(c.Auth.GrpcCtx, &jito_pb.SubscribeBundleResultsRequest{}, opts...)
+	return c.NewBundleSubscriptionResultsContext(c.lifecycle.Context(), opts...)
+}
+
+// NewBundleSubscriptionResultsContext creates a new bundle subscription stream, allowing to receive information about
+// broadcasted bundles. The stream ends when ctx is done.
+func (c *Client) NewBundleSubscriptionResultsContext(ctx context.Context, opts ...grpc.CallOption) (jito_pb.SearcherService_SubscribeBundleResultsClient, error) {
+	return c.SearcherService.SubscribeBundleResults(c.Auth.AuthorizeContext(ctx), &jito_pb.SubscribeBundleResultsRequest{}, opts...)
 }
 
-// SendBundle sends a bundle of transaction(s) on chain through Jito.
+// SendBundle is SendBundleContext bound to the client lifetime.
 func (c *Client) SendBundle(transactions []*solana.Transaction, opts ...grpc.CallOption) (*jito_pb.SendBundleResponse, error) {
+	return c.SendBundleContext(c.lifecycle.Context(), transactions, opts...)
+}
+
+// SendBundleContext sends a bundle of transaction(s) on chain through Jito.
+func (c *Client) SendBundleContext(ctx context.Context, transactions []*solana.Transaction, opts ...grpc.CallOption) (*jito_pb.SendBundleResponse, error) {
 	bundle, err := c.AssembleBundle(transactions)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.SearcherService.SendBundle(c.Auth.GrpcCtx, &jito_pb.SendBundleRequest{Bundle: bundle}, opts...)
+	return c.SearcherService.SendBundle(c.Auth.AuthorizeContext(ctx), &jito_pb.SendBundleRequest{Bundle: bundle}, opts...)
 }
 
-// SpamBundle spams SendBundle (spam being the amount of bundles sent). If async is true, it will use goroutines.
+// SpamBundle is SpamBundleContext bound to the client lifetime.
 func (c *Client) SpamBundle(transactions []*solana.Transaction, spam int, async bool, opts ...grpc.CallOption) ([]*jito_pb.SendBundleResponse, []error) {
-	bundles := make([]*jito_pb.SendBundleResponse, spam)
-	errs := make([]error, spam)
+	return c.SpamBundleContext(c.lifecycle.Context(), transactions, spam, async, opts...)
+}
+
+// SpamBundleContext spams SendBundleContext (spam being the amount of bundles sent). If async is true, it will use goroutines
+// and wait for all of them before returning.
+func (c *Client) SpamBundleContext(ctx context.Context, transactions []*solana.Transaction, spam int, async bool, opts ...grpc.CallOption) ([]*jito_pb.SendBundleResponse, []error) {
+	bundles := make([]*jito_pb.SendBundleResponse, 0, spam)
+	errs := make([]error, 0, spam)
 	mu := sync.Mutex{}
+	var wg sync.WaitGroup
 
 	f := func() {
-		bundle, err := c.SendBundle(transactions, opts...)
+		bundle, err := c.SendBundleContext(ctx, transactions, opts...)
+		mu.Lock()
+		defer mu.Unlock()
 		if err != nil {
 			errs = append(errs, err)
 			return
 		}
-		mu.Lock()
 		bundles = append(bundles, bundle)
-		mu.Unlock()
 	}
 	for i := 0; i < spam; i++ {
 		if async {
-			go f()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f()
+			}()
 		} else {
 			f()
 		}
 	}
+	wg.Wait()
+
 	return bundles, errs
 }
 
@@ -459,8 +611,13 @@ type SendBundleResponse struct {
 	Id      int    `json:"id"`
 }
 
-// SendBundle sends a bundle through Jito API.
+// SendBundle is SendBundleContext with a background context.
 func SendBundle(client *http.Client, encoding Encoding, transactions []*solana.Transaction) (*SendBundleResponse, error) {
+	return SendBundleContext(context.Background(), client, encoding, transactions)
+}
+
+// SendBundleContext sends a bundle through Jito API.
+func SendBundleContext(ctx context.Context, client *http.Client, encoding Encoding, transactions []*solana.Transaction) (*SendBundleResponse, error) {
 	buf := new(bytes.Buffer)
 
 	var txns []string
@@ -504,7 +661,7 @@ func SendBundle(client *http.Client, encoding Encoding, transactions []*solana.T
 		Header: DefaultHeader.Clone(),
 	}
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -521,7 +678,7 @@ func SendBundle(client *http.Client, encoding Encoding, transactions []*solana.T
 
 // SendBundleWithConfirmation sends a bundle of transactions on chain through Jito BlockEngine and waits for its confirmation.
 func SendBundleWithConfirmation(ctx context.Context, client *http.Client, rpcConn *rpc.Client, encoding Encoding, transactions []*solana.Transaction) (*SendBundleResponse, error) {
-	bundle, err := SendBundle(client, encoding, transactions)
+	bundle, err := SendBundleContext(ctx, client, encoding, transactions)
 	if err != nil {
 		return nil, err
 	}
@@ -536,7 +693,7 @@ func SendBundleWithConfirmation(ctx context.Context, client *http.Client, rpcCon
 		default:
 			time.Sleep(3 * time.Second)
 
-			bundleStatuses, err := GetInflightBundleStatuses(client, []string{bundle.Result})
+			bundleStatuses, err := GetInflightBundleStatusesContext(ctx, client, []string{bundle.Result})
 			if err != nil {
 				return bundle, err
 			}
@@ -589,7 +746,11 @@ func SendBundleWithConfirmation(ctx context.Context, client *http.Client, rpcCon
 
 // SendBundleWithConfirmation sends a bundle of transactions on chain through Jito BlockEngine and waits for its confirmation.
 func (c *Client) SendBundleWithConfirmation(ctx context.Context, transactions []*solana.Transaction, opts ...grpc.CallOption) (*jito_pb.SendBundleResponse, error) {
-	bundle, err := c.SendBundle(transactions, opts...)
+	// subscribing first ensures the result cannot be published before we listen
+	results := pkg.Subscribe[pkg.BundleResultEvent](c.Events(), 64)
+	defer results.Cancel()
+
+	bundle, err := c.SendBundleContext(ctx, transactions, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -599,15 +760,18 @@ func (c *Client) SendBundleWithConfirmation(ctx context.Context, transactions []
 
 	for {
 		select {
-		case <-c.Auth.GrpcCtx.Done():
-			return nil, c.Auth.GrpcCtx.Err()
-		default:
-			bundleResult, err := c.BundleStreamSubscription.Recv()
-			if err != nil {
-				return bundle, err
+		case <-ctx.Done():
+			return bundle, ctx.Err()
+		case event, ok := <-results.C:
+			if !ok {
+				return bundle, errors.New("client closed before the bundle result was received")
+			}
+
+			if event.Result.GetBundleId() != bundle.GetUuid() {
+				continue
 			}
 
-			if err = handleBundleResult(bundleResult, ""); err != nil {
+			if err = handleBundleResult(event.Result, ""); err != nil {
 				return bundle, err
 			}
 
@@ -650,6 +814,146 @@ func (c *Client) SendBundleWithConfirmation(ctx context.Context, transactions []
 	}
 }
 
+var ErrBundleNotLanded = errors.New("bundle did not land")
+
+// SubmitUntilLanded sends the bundle built by config.Build and resends it, rebuilt with a fresh blockhash and the next tip
+// of config.Tips, whenever it is dropped, loses an auction or does not land within config.ResultTimeout.
+// It stops when the bundle lands, fails simulation, or once config.MaxAttempts or the deadline of ctx is reached;
+// the returned error then wraps ErrBundleNotLanded and the reason of the last failure.
+func (c *Client) SubmitUntilLanded(ctx context.Context, config SubmitConfig, opts ...grpc.CallOption) (*SubmitResult, error) {
+	if config.Build == nil {
+		return nil, errors.New("SubmitConfig.Build is required")
+	}
+	if config.Blockhashes == nil && c.RpcConn == nil {
+		return nil, errors.New("a blockhash provider or a rpc client is required")
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.ResultTimeout <= 0 {
+		config.ResultTimeout = 10 * time.Second
+	}
+
+	results := pkg.Subscribe[pkg.BundleResultEvent](c.Events(), 256)
+	defer results.Cancel()
+
+	var reason error
+	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
+		blockhash, err := c.submitBlockhash(ctx, config.Blockhashes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBundleNotLanded, err)
+		}
+
+		var tip uint64
+		if len(config.Tips) > 0 {
+			tip = config.Tips[min(attempt, len(config.Tips))-1]
+		}
+
+		transactions, err := config.Build(ctx, SubmitAttempt{Attempt: attempt, Blockhash: blockhash, Tip: tip, Reason: reason})
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to build bundle: %w", ErrBundleNotLanded, err)
+		}
+
+		bundle, err := c.SendBundleContext(ctx, transactions, opts...)
+		if err != nil {
+			if ctx.Err() != nil || !pkg.IsRetryable(err) {
+				return nil, fmt.Errorf("%w: %w", ErrBundleNotLanded, err)
+			}
+			reason = err
+			continue
+		}
+
+		result, retry, err := c.awaitLanding(ctx, results, bundle.GetUuid(), transactions, config.ResultTimeout)
+		if err == nil {
+			return &SubmitResult{BundleID: bundle.GetUuid(), Attempts: attempt, Tip: tip, Result: result}, nil
+		}
+		if !retry {
+			return nil, fmt.Errorf("%w: %w", ErrBundleNotLanded, err)
+		}
+		reason = err
+	}
+
+	return nil, fmt.Errorf("%w after %d attempts: %w", ErrBundleNotLanded, config.MaxAttempts, reason)
+}
+
+// submitBlockhash returns the blockhash of the next SubmitUntilLanded attempt.
+func (c *Client) submitBlockhash(ctx context.Context, provider *pkg.BlockhashProvider) (solana.Hash, error) {
+	if provider == nil {
+		latest, err := c.RpcConn.GetLatestBlockhash(ctx, rpc.CommitmentConfirmed)
+		if err != nil {
+			return solana.Hash{}, err
+		}
+		return latest.Value.Blockhash, nil
+	}
+
+	if latest, err := provider.Latest(); err == nil && !provider.IsExpiring(latest.Hash) {
+		return latest.Hash, nil
+	}
+	if err := provider.Refresh(ctx); err != nil {
+		return solana.Hash{}, err
+	}
+
+	latest, err := provider.Latest()
+	return latest.Hash, err
+}
+
+// awaitLanding waits for the bundle uuid to be processed. retry tells whether resending the bundle may help when err is set.
+func (c *Client) awaitLanding(ctx context.Context, results *pkg.Subscription[pkg.BundleResultEvent], uuid string, transactions []*solana.Transaction, timeout time.Duration) (*jito_pb.BundleResult, bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-timer.C:
+			// results are best effort, the bundle may have landed without us being told
+			if c.landed(ctx, transactions) {
+				return nil, false, nil
+			}
+			return nil, true, fmt.Errorf("bundle %s did not land within %s", uuid, timeout)
+		case event, ok := <-results.C:
+			if !ok {
+				return nil, false, errors.New("client closed before the bundle landed")
+			}
+
+			result := event.Result
+			if result.GetBundleId() != uuid {
+				continue
+			}
+
+			switch {
+			case result.GetProcessed() != nil, result.GetFinalized() != nil:
+				return result, false, nil
+			case result.GetDropped() != nil:
+				return nil, true, fmt.Errorf("bundle %s dropped: %s", uuid, result.GetDropped().GetReason())
+			case result.GetRejected() != nil:
+				// a bundle failing simulation fails again until the caller fixes it
+				return nil, result.GetRejected().GetSimulationFailure() == nil, handleBundleResult(result, "")
+			}
+		}
+	}
+}
+
+// landed reports whether every transaction of the bundle is known by RpcConn without error.
+func (c *Client) landed(ctx context.Context, transactions []*solana.Transaction) bool {
+	if c.RpcConn == nil {
+		return false
+	}
+
+	statuses, err := c.RpcConn.GetSignatureStatuses(ctx, true, pkg.BatchExtractSigFromTx(transactions)...)
+	if err != nil {
+		return false
+	}
+
+	for _, status := range statuses.Value {
+		if status == nil || status.Err != nil {
+			return false
+		}
+	}
+	return len(statuses.Value) > 0
+}
+
 // bundleID arg is solely for JSON RPC API.
 func handleBundleResult[T *GetInflightBundlesStatusesResponse | *jito_pb.BundleResult](t T, bundleID string) error {
 	switch bundle := any(t).(type) {
@@ -769,8 +1073,13 @@ func (c *Client) BatchGetBundleStatuses(ctx context.Context, bundleIDs ...string
 	}
 }
 
-// GetBundleStatuses returns the status of submitted bundle(s). This function operates similarly to the Solana RPC method getSignatureStatuses.
+// GetBundleStatuses is GetBundleStatusesContext with a background context.
 func GetBundleStatuses(client *http.Client, bundleIDs []string) (*BundleStatusesResponse, error) {
+	return GetBundleStatusesContext(context.Background(), client, bundleIDs)
+}
+
+// GetBundleStatusesContext returns the status of submitted bundle(s). This function operates similarly to the Solana RPC method getSignatureStatuses.
+func GetBundleStatusesContext(ctx context.Context, client *http.Client, bundleIDs []string) (*BundleStatusesResponse, error) {
 	if len(bundleIDs) > 5 {
 		return nil, fmt.Errorf("max length reached (exp 5, got %d), please use BatchGetBundleStatuses or reduce the amount of bundles", len(bundleIDs))
 	}
@@ -794,7 +1103,7 @@ func GetBundleStatuses(client *http.Client, bundleIDs []string) (*BundleStatuses
 		Header: DefaultHeader.Clone(),
 	}
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("error performing GetBundleStatuses: client error > %w", err)
 	}
@@ -809,9 +1118,14 @@ func GetBundleStatuses(client *http.Client, bundleIDs []string) (*BundleStatuses
 	return &out, err
 }
 
-// BatchGetBundleStatuses returns the statuses of multiple submitted bundles by splitting the bundleIDs into groups of up to 5
-// and calling GetBundleStatuses on each group.
+// BatchGetBundleStatuses is BatchGetBundleStatusesContext with a background context.
 func BatchGetBundleStatuses(client *http.Client, bundleIDs ...string) ([]*BundleStatusesResponse, error) {
+	return BatchGetBundleStatusesContext(context.Background(), client, bundleIDs...)
+}
+
+// BatchGetBundleStatusesContext returns the statuses of multiple submitted bundles by splitting the bundleIDs into groups of up to 5
+// and calling GetBundleStatuses on each group.
+func BatchGetBundleStatusesContext(ctx context.Context, client *http.Client, bundleIDs ...string) ([]*BundleStatusesResponse, error) {
 	if len(bundleIDs) > 5 {
 		var bundles [][]string
 		var out []*BundleStatusesResponse
@@ -825,7 +1139,7 @@ func BatchGetBundleStatuses(client *http.Client, bundleIDs ...string) ([]*Bundle
 		}
 
 		for _, bundle := range bundles {
-			resp, err := GetBundleStatuses(client, bundle)
+			resp, err := GetBundleStatusesContext(ctx, client, bundle)
 			if err != nil {
 				return out, err
 			}
@@ -837,7 +1151,7 @@ func BatchGetBundleStatuses(client *http.Client, bundleIDs ...string) ([]*Bundle
 	} else {
 		var out []*BundleStatusesResponse
 
-		resp, err := GetBundleStatuses(client, bundleIDs)
+		resp, err := GetBundleStatusesContext(ctx, client, bundleIDs)
 		if err != nil {
 			return nil, err
 		}
@@ -865,8 +1179,13 @@ func (c *Client) AssembleBundle(transactions []*solana.Transaction) (*jito_pb.Bu
 	return &jito_pb.Bundle{Packets: packets, Header: nil}, nil
 }
 
-// GetInflightBundleStatuses returns the status of submitted bundles within the last five minutes, allowing up to five bundle IDs per request.
+// GetInflightBundleStatuses is GetInflightBundleStatusesContext with a background context.
 func GetInflightBundleStatuses(client *http.Client, bundles []string) (*GetInflightBundlesStatusesResponse, error) {
+	return GetInflightBundleStatusesContext(context.Background(), client, bundles)
+}
+
+// GetInflightBundleStatusesContext returns the status of submitted bundles within the last five minutes, allowing up to five bundle IDs per request.
+func GetInflightBundleStatusesContext(ctx context.Context, client *http.Client, bundles []string) (*GetInflightBundlesStatusesResponse, error) {
 	buf := new(bytes.Buffer)
 
 	payload := map[string]any{
@@ -889,7 +1208,7 @@ func GetInflightBundleStatuses(client *http.Client, bundles []string) (*GetInfli
 		Header: DefaultHeader.Clone(),
 	}
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -904,8 +1223,13 @@ func GetInflightBundleStatuses(client *http.Client, bundles []string) (*GetInfli
 	return &out, err
 }
 
-// GetTipAccounts retrieves the tip accounts designated for tip payments for bundles.
+// GetTipAccounts is GetTipAccountsContext with a background context.
 func GetTipAccounts(client *http.Client) (*GetTipAccountsResponse, error) {
+	return GetTipAccountsContext(context.Background(), client)
+}
+
+// GetTipAccountsContext retrieves the tip accounts designated for tip payments for bundles.
+func GetTipAccountsContext(ctx context.Context, client *http.Client) (*GetTipAccountsResponse, error) {
 	buf := new(bytes.Buffer)
 
 	payload := map[string]any{
@@ -926,7 +1250,7 @@ func GetTipAccounts(client *http.Client) (*GetTipAccountsResponse, error) {
 		Header: DefaultHeader.Clone(),
 	}
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -941,13 +1265,18 @@ func GetTipAccounts(client *http.Client) (*GetTipAccountsResponse, error) {
 	return &out, err
 }
 
-// SendTransaction serves as a proxy to the Solana sendTransaction RPC method.
+// SendTransaction is SendTransactionContext with a background context.
+func SendTransaction(client *http.Client, sig string, bundleOnly bool, encoding Encoding) (*TransactionResponse, error) {
+	return SendTransactionContext(context.Background(), client, sig, bundleOnly, encoding)
+}
+
+// SendTransactionContext serves as a proxy to the Solana sendTransaction RPC method.
 // It forwards the received transaction as a regular Solana transaction via the Solana RPC method and submits it as a bundle.
 // Jito no longer provides a minimum tip for the bundle.
 // Please note that this minimum tip might not be sufficient to get the bundle through the auction, especially during high-demand periods.
 // Additionally, you need to set a priority fee and jito tip to ensure this transaction is set up correctly.
 // Otherwise, if you set the query parameter bundleOnly=true, the transaction will only be sent out as a bundle and not as a regular transaction via RPC.
-func SendTransaction(client *http.Client, sig string, bundleOnly bool, encoding Encoding) (*TransactionResponse, error) {
+func SendTransactionContext(ctx context.Context, client *http.Client, sig string, bundleOnly bool, encoding Encoding) (*TransactionResponse, error) {
 	buf := new(bytes.Buffer)
 
 	params := []any{sig}
@@ -984,7 +1313,7 @@ func SendTransaction(client *http.Client, sig string, bundleOnly bool, encoding
 		Header: DefaultHeader.Clone(),
 	}
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -1009,9 +1338,14 @@ func GenerateTipInstruction(tipAmount uint64, from, tipAccount solana.PublicKey)
 	return system.NewTransferInstruction(tipAmount, from, tipAccount).Build()
 }
 
-// GenerateTipRandomAccountInstruction functions similarly to GenerateTipInstruction, but it selects a random tip account.
+// GenerateTipRandomAccountInstruction is GenerateTipRandomAccountInstructionContext bound to the client lifetime.
 func (c *Client) GenerateTipRandomAccountInstruction(tipAmount uint64, from solana.PublicKey) (solana.Instruction, error) {
-	tipAccount, err := c.GetRandomTipAccount()
+	return c.GenerateTipRandomAccountInstructionContext(c.lifecycle.Context(), tipAmount, from)
+}
+
+// GenerateTipRandomAccountInstructionContext functions similarly to GenerateTipInstruction, but it selects a random tip account.
+func (c *Client) GenerateTipRandomAccountInstructionContext(ctx context.Context, tipAmount uint64, from solana.PublicKey) (solana.Instruction, error) {
+	tipAccount, err := c.GetRandomTipAccountContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1019,6 +1353,30 @@ func (c *Client) GenerateTipRandomAccountInstruction(tipAmount uint64, from sola
 	return system.NewTransferInstruction(tipAmount, from, solana.MustPublicKeyFromBase58(tipAccount)).Build(), nil
 }
 
+// NewBlockhashProvider returns a pkg.BlockhashProvider fed by RpcConn, refreshed until ctx is done or the client is closed.
+// Build bundles with its Latest blockhash instead of calling GetLatestBlockhash for every bundle.
+func (c *Client) NewBlockhashProvider(ctx context.Context, config pkg.BlockhashProviderConfig) (*pkg.BlockhashProvider, error) {
+	if c.RpcConn == nil {
+		return nil, errors.New("a rpc client is required, see pkg.WithRPCClients")
+	}
+	if config.Events == nil {
+		config.Events = c.Events()
+	}
+
+	provider := pkg.NewBlockhashProvider(c.RpcConn, config)
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	if err := provider.Refresh(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+	c.lifecycle.Go(func(context.Context) {
+		defer cancel()
+		provider.Run(ctx)
+	})
+
+	return provider, nil
+}
+
 func isRPCNil(client *rpc.Client) {
 	if client == nil {
 		client = rpc.New(rpc.MainNetBeta_RPC)
@@ -1063,30 +1421,8 @@ func NewDroppedBundle(message string) error {
 	}
 }
 
-// AddProxyToHttpClient adds a proxy to an existing HTTP client.
+// AddProxyToHttpClient adds a proxy to an existing HTTP client, used by the JSON-RPC functions.
+// It accepts the same proxy formats as New.
 func AddProxyToHttpClient(client *http.Client, proxy string) error {
-	if client == nil {
-		client = &http.Client{}
-	}
-
-	transport := client.Transport
-	if transport == nil {
-		transport = http.DefaultTransport
-	}
-
-	proxyURL, err := url.Parse(proxy)
-	if err != nil {
-		return fmt.Errorf("failed to parse proxy URL: %w", err)
-	}
-
-	t, ok := transport.(*http.Transport)
-	if !ok {
-		return errors.New("client transport is not an *http.Transport")
-	}
-
-	newTransport := t.Clone()
-	newTransport.Proxy = http.ProxyURL(proxyURL)
-	client.Transport = newTransport
-
-	return nil
+	return pkg.SetHTTPProxy(client, proxy)
 }
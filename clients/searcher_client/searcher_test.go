@@ -2,6 +2,8 @@ package searcher_client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -9,11 +11,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/weeaa/jito-go"
 	"github.com/weeaa/jito-go/pb"
+	"github.com/weeaa/jito-go/pkg"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -616,3 +623,136 @@ func TestHandleBundleResult(t *testing.T) {
 	})
 }
 */
+
+type fakeSearcher struct {
+	jito_pb.UnimplementedSearcherServiceServer
+	sent    atomic.Int32
+	results chan *jito_pb.BundleResult
+}
+
+func (s *fakeSearcher) SendBundle(context.Context, *jito_pb.SendBundleRequest) (*jito_pb.SendBundleResponse, error) {
+	uuid := fmt.Sprintf("bundle-%d", s.sent.Add(1))
+	if uuid == "bundle-1" {
+		s.results <- &jito_pb.BundleResult{BundleId: uuid, Result: &jito_pb.BundleResult_Rejected{Rejected: &jito_pb.Rejected{
+			Reason: &jito_pb.Rejected_StateAuctionBidRejected{StateAuctionBidRejected: &jito_pb.StateAuctionBidRejected{AuctionId: "auction"}},
+		}}}
+	} else {
+		s.results <- &jito_pb.BundleResult{BundleId: uuid, Result: &jito_pb.BundleResult_Processed{Processed: &jito_pb.Processed{}}}
+	}
+	return &jito_pb.SendBundleResponse{Uuid: uuid}, nil
+}
+
+func (s *fakeSearcher) SubscribeBundleResults(_ *jito_pb.SubscribeBundleResultsRequest, stream jito_pb.SearcherService_SubscribeBundleResultsServer) error {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case result := <-s.results:
+			if err := stream.Send(result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestSubmitUntilLanded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	server := grpc.NewServer()
+	jito_pb.RegisterSearcherServiceServer(server, &fakeSearcher{results: make(chan *jito_pb.BundleResult, 8)})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	blockhash := solana.Hash{7}
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"context":{"slot":1},"value":{"blockhash":"%s","lastValidBlockHeight":150}}}`, req.ID, blockhash)
+	}))
+	defer rpcServer.Close()
+
+	client, err := NewWithOptions(ctx, pkg.WithEndpoint(lis.Addr().String()), pkg.WithInsecure(), pkg.WithNoAuth(), pkg.WithRPCClients(rpc.New(rpcServer.URL), nil))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer client.Close()
+
+	payer := solana.NewWallet()
+	var attempts []SubmitAttempt
+	result, err := client.SubmitUntilLanded(ctx, SubmitConfig{
+		Tips: []uint64{1000, 2000},
+		Build: func(ctx context.Context, attempt SubmitAttempt) ([]*solana.Transaction, error) {
+			attempts = append(attempts, attempt)
+			tx, err := solana.NewTransaction([]solana.Instruction{
+				GenerateTipInstruction(attempt.Tip, payer.PublicKey(), jito_go.MainnetTipAccounts[0]),
+			}, attempt.Blockhash, solana.TransactionPayer(payer.PublicKey()))
+			if err != nil {
+				return nil, err
+			}
+			_, err = tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &payer.PrivateKey })
+			return []*solana.Transaction{tx}, err
+		},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "bundle-2", result.BundleID)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, uint64(2000), result.Tip)
+	assert.NotNil(t, result.Result.GetProcessed())
+
+	if assert.Len(t, attempts, 2) {
+		assert.Equal(t, blockhash, attempts[1].Blockhash)
+		assert.NoError(t, attempts[0].Reason)
+		assert.Error(t, attempts[1].Reason)
+	}
+}
+
+func TestOpts(t *testing.T) {
+	rpcClient, jitoRpcClient := rpc.New(rpc.MainNetBeta_RPC), rpc.New(rpc.MainNetBeta_RPC)
+	key := solana.NewWallet().PrivateKey
+	opts := Opts{
+		BlockEngineURL: jito_go.NewYork.BlockEngineURL,
+		JitoRpcClient:  jitoRpcClient,
+		RpcClient:      rpcClient,
+		PrivateKey:     key,
+		ProxyURL:       "socks5://1.2.3.4:1080",
+		GrpcOptions:    []grpc.DialOption{grpc.WithUserAgent("jito-go")},
+	}
+
+	o := pkg.NewClientOptions(opts.Options()...)
+	assert.Equal(t, jito_go.NewYork.BlockEngineURL, o.Endpoint)
+	assert.Same(t, rpcClient, o.RpcClient)
+	assert.Same(t, jitoRpcClient, o.JitoRpcClient)
+	assert.Equal(t, key, o.PrivateKey)
+	assert.Equal(t, "socks5://1.2.3.4:1080", o.ProxyURL)
+	assert.Len(t, o.DialOptions, 1)
+}
+
+func TestHTTPContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := SendBundleContext(ctx, http.DefaultClient, Base64, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = GetBundleStatusesContext(ctx, http.DefaultClient, []string{"bundle"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = BatchGetBundleStatusesContext(ctx, http.DefaultClient, "1", "2", "3", "4", "5", "6")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = GetInflightBundleStatusesContext(ctx, http.DefaultClient, []string{"bundle"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = GetTipAccountsContext(ctx, http.DefaultClient)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = SendTransactionContext(ctx, http.DefaultClient, "tx", true, Base64)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = pkg.GetTipInformationContext(ctx, http.DefaultClient)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -1,24 +1,22 @@
 package searcher_client
 
 import (
+	"context"
 	"crypto/tls"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/weeaa/jito-go/pb"
 	"github.com/weeaa/jito-go/pkg"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/keepalive"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
-var defaultKeepAlive = grpc.WithKeepaliveParams(keepalive.ClientParameters{
-	Time:                15 * time.Second,
-	Timeout:             5 * time.Second,
-	PermitWithoutStream: true,
-})
+var defaultKeepAlive = grpc.WithKeepaliveParams(pkg.DefaultKeepAlive)
 
 var MINIMUM_TIP uint64 = 1000
 
@@ -45,18 +43,82 @@ var jitoBundleURL = &url.URL{
 }
 
 type Client struct {
+	// GrpcConn is the connection dialed by the constructor, it is closed once the Supervisor recreates it.
+	//
+	// Deprecated: use Supervisor.Conn, which returns the live connection.
 	GrpcConn    *grpc.ClientConn
-	RpcConn     *rpc.Client // Utilized for executing standard Solana RPC requests.
-	JitoRpcConn *rpc.Client // Utilized for executing specific Jito RPC requests (Jito node required).
+	Supervisor  *pkg.ConnSupervisor // Owns the connection and recreates it when it dies.
+	RpcConn     *rpc.Client         // Utilized for executing standard Solana RPC requests.
+	JitoRpcConn *rpc.Client         // Utilized for executing specific Jito RPC requests (Jito node required).
 
-	SearcherService          jito_pb.SearcherServiceClient
-	BundleStreamSubscription jito_pb.SearcherService_SubscribeBundleResultsClient // Used for receiving *jito_pb.BundleResult (bundle broadcast status info).
+	SearcherService jito_pb.SearcherServiceClient
+	// BundleStreamSubscription used to receive *jito_pb.BundleResult (bundle broadcast status info).
+	//
+	// Deprecated: bundle results are published as pkg.BundleResultEvent on Events. The stream is opened on its first use
+	// and ends with the client, it is not re-opened when the connection is recreated.
+	BundleStreamSubscription jito_pb.SearcherService_SubscribeBundleResultsClient
 
 	Auth *pkg.AuthenticationService
 
-	ErrChan chan error // ErrChan is used for dispatching errors from functions executed within goroutines.
+	// ErrChan is used for dispatching errors from functions executed within goroutines, errors are dropped when it is full.
+	//
+	// Deprecated: subscribe to pkg.ErrorEvent on Events instead.
+	ErrChan chan error
+
+	lifecycle *pkg.Lifecycle
+	logger    *slog.Logger
+	dialOpts  []grpc.DialOption // dial options without proxy, reused when rotating proxies.
+}
+
+// lazyBundleStream opens a bundle results stream on its first use, for the callers of BundleStreamSubscription.
+type lazyBundleStream struct {
+	ctx    context.Context
+	open   func(ctx context.Context) (jito_pb.SearcherService_SubscribeBundleResultsClient, error)
+	once   sync.Once
+	stream jito_pb.SearcherService_SubscribeBundleResultsClient
+	err    error
+}
+
+// HedgedClient sends latency critical reads to Clients[0] first, then to the next client every Delay without an answer,
+// keeping the first successful response, see pkg.Hedge.
+type HedgedClient struct {
+	Clients []*Client
+	Delay   time.Duration
 }
 
+// SubmitAttempt is passed to SubmitConfig.Build before every send.
+type SubmitAttempt struct {
+	Attempt   int         // starts at 1.
+	Blockhash solana.Hash // fresh blockhash to sign the transactions with.
+	Tip       uint64      // tip in lamports from SubmitConfig.Tips, 0 when no schedule is set.
+	Reason    error       // why the previous attempt did not land, nil on the first attempt.
+}
+
+type SubmitConfig struct {
+	// Build returns the signed transactions of the bundle for attempt, it is called again each time the bundle must be resent.
+	Build func(ctx context.Context, attempt SubmitAttempt) ([]*solana.Transaction, error)
+
+	// Blockhashes provides the blockhashes, RpcConn is queried on every attempt when nil.
+	Blockhashes *pkg.BlockhashProvider
+
+	// Tips is the tip schedule, attempt n uses Tips[n-1] and the last tip is kept once the schedule is exhausted.
+	Tips []uint64
+
+	MaxAttempts   int           // defaults to 5, the deadline of ctx also bounds the submission.
+	ResultTimeout time.Duration // time to wait for the bundle to land before resending it, defaults to 10s.
+}
+
+// SubmitResult describes the bundle which landed.
+type SubmitResult struct {
+	BundleID string
+	Attempts int
+	Tip      uint64
+	Result   *jito_pb.BundleResult // Processed or Finalized result, nil when the landing was seen through RpcConn.
+}
+
+// Opts holds the arguments of New.
+//
+// Deprecated: use NewWithOptions, Options returns the equivalent pkg.ClientOption values.
 type Opts struct {
 	BlockEngineURL           string
 	JitoRpcClient, RpcClient *rpc.Client
@@ -66,6 +128,18 @@ type Opts struct {
 	GrpcOptions              []grpc.DialOption
 }
 
+// Options returns the pkg.ClientOption values equivalent to o, e.g. NewWithOptions(ctx, opts.Options()...).
+func (o Opts) Options() []pkg.ClientOption {
+	return []pkg.ClientOption{
+		pkg.WithEndpoint(o.BlockEngineURL),
+		pkg.WithRPCClients(o.RpcClient, o.JitoRpcClient),
+		pkg.WithPrivateKey(o.PrivateKey),
+		pkg.WithProxy(o.ProxyURL),
+		pkg.WithTLSConfig(o.TLSConfig),
+		pkg.WithDialOptions(o.GrpcOptions...),
+	}
+}
+
 type SimulateBundleConfig struct {
 	PreExecutionAccountsConfigs  []ExecutionAccounts `json:"preExecutionAccountsConfigs"`
 	PostExecutionAccountsConfigs []ExecutionAccounts `json:"postExecutionAccountsConfigs"`
@@ -0,0 +1,307 @@
+package shredstream_client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"github.com/weeaa/jito-go/pkg"
+	"google.golang.org/grpc"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewWithOptions creates a new ShredStream client authenticated with the shredstream subscriber role, pkg.WithPrivateKey
+// is required. Without pkg.WithEndpoint, pkg.WithFastestRegion connects to the block engine of the fastest region.
+func NewWithOptions(ctx context.Context, opts ...pkg.ClientOption) (*Client, error) {
+	o := pkg.NewClientOptions(opts...)
+	if o.PrivateKey == nil {
+		return nil, errors.New("shredstream client requires a private key, use pkg.WithPrivateKey")
+	}
+
+	endpoint, err := o.ResolveEndpoint(ctx, func(info jito_go.JitoEndpointInfo) string { return info.BlockEngineURL })
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle := pkg.NewLifecycle(ctx, 16)
+	supervisor, err := o.Dial(lifecycle, endpoint)
+	if err != nil {
+		lifecycle.Close()
+		return nil, err
+	}
+
+	shredstreamClient := jito_pb.NewShredstreamClient(supervisor)
+	authService := pkg.NewAuthenticationService(supervisor, o.PrivateKey)
+	authService.Events = lifecycle.Events()
+	lifecycle.OnClose(authService.Close)
+	if err = authService.AuthenticateAndRefreshContext(lifecycle.Context(), jito_pb.Role_SHREDSTREAM_SUBSCRIBER); err != nil {
+		lifecycle.Close()
+		return nil, err
+	}
+
+	client := &Client{
+		GrpcConn:    supervisor.Conn(),
+		Supervisor:  supervisor,
+		Shredstream: shredstreamClient,
+		Auth:        authService,
+		lifecycle:   lifecycle,
+	}
+
+	return client, nil
+}
+
+// New creates a new ShredStream client, use NewWithOptions and pkg.WithProxy to connect through a proxy.
+func New(ctx context.Context, grpcDialURL string, privateKey solana.PrivateKey, tlsConfig *tls.Config, opts ...grpc.DialOption) (*Client, error) {
+	return NewWithOptions(ctx,
+		pkg.WithEndpoint(grpcDialURL),
+		pkg.WithPrivateKey(privateKey),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithDialOptions(opts...),
+	)
+}
+
+// Close stops every goroutine of the client, waits for them and closes the connection, it can be called several times.
+func (c *Client) Close() error {
+	return c.lifecycle.Close()
+}
+
+// Events returns the event bus of the client, subscribe with pkg.Subscribe.
+func (c *Client) Events() *pkg.EventBus {
+	return c.lifecycle.Events()
+}
+
+// SendHeartbeat is SendHeartbeatContext bound to the client lifetime.
+func (c *Client) SendHeartbeat(socket *jito_pb.Socket, regions []string, opts ...grpc.CallOption) (*jito_pb.HeartbeatResponse, error) {
+	return c.SendHeartbeatContext(c.lifecycle.Context(), socket, regions, opts...)
+}
+
+// SendHeartbeatContext asks the block engine to send the shreds of regions to socket until the returned TTL elapses.
+func (c *Client) SendHeartbeatContext(ctx context.Context, socket *jito_pb.Socket, regions []string, opts ...grpc.CallOption) (*jito_pb.HeartbeatResponse, error) {
+	req := &jito_pb.HeartbeatShredStream{Socket: socket, Regions: regions}
+	return c.Shredstream.SendHeartbeat(c.Auth.AuthorizeContext(ctx), req, opts...)
+}
+
+// LookupPublicIP fetches the public IP of the host from url, which must answer with the IP in plain text.
+func LookupPublicIP(ctx context.Context, httpClient *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup public ip: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to lookup public ip: unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup public ip: %w", err)
+	}
+
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("invalid public ip %q", ip)
+	}
+	return ip, nil
+}
+
+// StartHeartbeat sends a first heartbeat then keeps heartbeating in the background, halfway through the TTL of the
+// previous heartbeat, until ctx is done or the client is closed. Every heartbeat is published as a pkg.HeartbeatEvent,
+// failed ones are retried every RetryInterval. Without config.IP, the public IP is looked up and followed.
+func (c *Client) StartHeartbeat(ctx context.Context, config HeartbeatConfig) (*Heartbeater, error) {
+	if config.PublicIPURL == "" {
+		config.PublicIPURL = DefaultPublicIPURL
+	}
+	if config.IPCheckInterval <= 0 {
+		config.IPCheckInterval = 5 * time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+
+	ip := config.IP
+	if ip == "" {
+		var err error
+		if ip, err = LookupPublicIP(ctx, config.HTTPClient, config.PublicIPURL); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := c.lifecycle.Bind(ctx)
+	h := &Heartbeater{
+		client:  c,
+		config:  config,
+		socket:  &jito_pb.Socket{Ip: ip, Port: config.Port},
+		changed: make(chan struct{}, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	if err := h.beat(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c.lifecycle.Go(func(context.Context) {
+		defer close(h.done)
+		defer cancel()
+		h.run(ctx)
+	})
+
+	return h, nil
+}
+
+func (h *Heartbeater) run(ctx context.Context) {
+	timer := time.NewTimer(h.TTL() / 2)
+	defer timer.Stop()
+
+	// a nil channel never fires, which disables the public IP checks
+	var ipCheck <-chan time.Time
+	if h.config.IP == "" {
+		ticker := time.NewTicker(h.config.IPCheckInterval)
+		defer ticker.Stop()
+		ipCheck = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ipCheck:
+			ip, err := LookupPublicIP(ctx, h.config.HTTPClient, h.config.PublicIPURL)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.client.lifecycle.Dispatch(err)
+				}
+				continue
+			}
+			h.SetSocket(ip, h.Socket().GetPort())
+			continue
+		case <-h.changed:
+		case <-timer.C:
+		}
+
+		next := h.config.RetryInterval
+		if err := h.beat(ctx); err == nil {
+			next = h.TTL() / 2
+		} else if ctx.Err() != nil {
+			return
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(next)
+	}
+}
+
+// beat sends a heartbeat for the current socket and publishes its outcome.
+func (h *Heartbeater) beat(ctx context.Context) error {
+	socket := h.Socket()
+	resp, err := h.client.SendHeartbeatContext(ctx, socket, h.config.Regions)
+	if err != nil {
+		err = fmt.Errorf("failed to send shredstream heartbeat: %w", err)
+		h.client.lifecycle.Events().Publish(pkg.HeartbeatEvent{Socket: socket, Err: err, Time: time.Now()})
+		return err
+	}
+
+	ttl := time.Duration(resp.GetTtlMs()) * time.Millisecond
+	if ttl <= 0 {
+		ttl = DefaultHeartbeatTTL
+	}
+
+	h.mu.Lock()
+	h.ttl = ttl
+	h.last = time.Now()
+	h.mu.Unlock()
+
+	h.client.lifecycle.Events().Publish(pkg.HeartbeatEvent{Socket: socket, TTL: ttl, Time: time.Now()})
+	return nil
+}
+
+// ListenShreds receives the shreds sent to the advertised socket, listening on its port on every interface,
+// until ctx is done or the client is closed.
+func (h *Heartbeater) ListenShreds(ctx context.Context, config pkg.ShredReceiverConfig) (*pkg.ShredReceiver, error) {
+	ctx, cancel := h.client.lifecycle.Bind(ctx)
+	if config.Events == nil {
+		config.Events = h.client.lifecycle.Events()
+	}
+
+	receiver, err := pkg.ListenShreds(ctx, net.JoinHostPort("", strconv.FormatInt(h.Socket().GetPort(), 10)), config)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// release the lifecycle binding once the receiver stops
+	context.AfterFunc(ctx, cancel)
+	return receiver, nil
+}
+
+// SetSocket changes the address shreds are sent to, a heartbeat is sent right away when it differs from the current one.
+func (h *Heartbeater) SetSocket(ip string, port int64) {
+	h.mu.Lock()
+	changed := h.socket.GetIp() != ip || h.socket.GetPort() != port
+	if changed {
+		h.socket = &jito_pb.Socket{Ip: ip, Port: port}
+	}
+	h.mu.Unlock()
+
+	if changed {
+		select {
+		case h.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Socket returns the address shreds are currently sent to.
+func (h *Heartbeater) Socket() *jito_pb.Socket {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.socket
+}
+
+// TTL returns how long the last successful heartbeat is valid for.
+func (h *Heartbeater) TTL() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.ttl
+}
+
+// LastHeartbeat returns when the last successful heartbeat was sent.
+func (h *Heartbeater) LastHeartbeat() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.last
+}
+
+// Stop stops heartbeating, the block engine stops sending shreds once the last heartbeat expires.
+func (h *Heartbeater) Stop() {
+	h.cancel()
+	<-h.done
+}
+
+// Done is closed once the heartbeater stopped.
+func (h *Heartbeater) Done() <-chan struct{} {
+	return h.done
+}
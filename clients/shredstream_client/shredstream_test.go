@@ -0,0 +1,169 @@
+package shredstream_client
+
+import (
+	"context"
+	"github.com/gagliardetto/solana-go"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"github.com/weeaa/jito-go/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	_, filename, _, _ := runtime.Caller(0)
+	godotenv.Load(filepath.Join(filepath.Dir(filename), "..", "..", "..", "jito-go", ".env"))
+	os.Exit(m.Run())
+}
+
+func Test_ShredstreamClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	privKey, ok := os.LookupEnv("PRIVATE_KEY")
+	if !assert.True(t, ok, "getting PRIVATE_KEY from .env") {
+		t.FailNow()
+	}
+
+	client, err := New(
+		ctx,
+		jito_go.Amsterdam.BlockEngineURL,
+		solana.MustPrivateKeyFromBase58(privKey),
+		nil,
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer client.Close()
+
+	t.Run("SendHeartbeat", func(t *testing.T) {
+		resp, err := client.SendHeartbeat(&jito_pb.Socket{Ip: "127.0.0.1", Port: 20_000}, []string{"amsterdam"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.NotZero(t, resp.TtlMs)
+	})
+}
+
+type fakeShredstream struct {
+	jito_pb.UnimplementedShredstreamServer
+	heartbeats chan *jito_pb.HeartbeatShredStream
+	failures   atomic.Int32 // amount of heartbeats to reject before accepting them.
+}
+
+func (s *fakeShredstream) SendHeartbeat(_ context.Context, req *jito_pb.HeartbeatShredStream) (*jito_pb.HeartbeatResponse, error) {
+	s.heartbeats <- req
+	if s.failures.Add(-1) >= 0 {
+		return nil, assert.AnError
+	}
+	return &jito_pb.HeartbeatResponse{TtlMs: 200}, nil
+}
+
+func newFakeClient(t *testing.T, ctx context.Context, server *fakeShredstream) *Client {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grpcServer := grpc.NewServer()
+	jito_pb.RegisterShredstreamServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	lifecycle := pkg.NewLifecycle(ctx, 0)
+	supervisor, err := pkg.NewConnSupervisor(lifecycle.Context(), nil, lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle.OnClose(supervisor.Close)
+
+	client := &Client{GrpcConn: supervisor.Conn(), Supervisor: supervisor, Shredstream: jito_pb.NewShredstreamClient(supervisor), Auth: &pkg.AuthenticationService{}, lifecycle: lifecycle}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestHeartbeater(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server := &fakeShredstream{heartbeats: make(chan *jito_pb.HeartbeatShredStream, 64)}
+	client := newFakeClient(t, ctx, server)
+	events := pkg.Subscribe[pkg.HeartbeatEvent](client.Events(), 64)
+	defer events.Cancel()
+
+	var publicIP atomic.Value
+	publicIP.Store("1.2.3.4")
+	ipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(publicIP.Load().(string) + "\n"))
+	}))
+	defer ipServer.Close()
+
+	heartbeater, err := client.StartHeartbeat(ctx, HeartbeatConfig{
+		Regions:         []string{"amsterdam", "ny"},
+		Port:            20_000,
+		PublicIPURL:     ipServer.URL,
+		IPCheckInterval: 50 * time.Millisecond,
+		RetryInterval:   10 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	first := <-server.heartbeats
+	assert.Equal(t, "1.2.3.4", first.GetSocket().GetIp())
+	assert.Equal(t, int64(20_000), first.GetSocket().GetPort())
+	assert.Equal(t, []string{"amsterdam", "ny"}, first.GetRegions())
+	assert.Equal(t, 200*time.Millisecond, heartbeater.TTL())
+
+	// the next heartbeat is sent before the first one expires
+	select {
+	case <-server.heartbeats:
+		assert.Less(t, time.Since(heartbeater.LastHeartbeat()), 200*time.Millisecond)
+	case <-ctx.Done():
+		t.Fatal("heartbeat not refreshed")
+	}
+
+	// a new public ip is sent right away, failures are published and retried
+	server.failures.Store(1)
+	publicIP.Store("5.6.7.8")
+
+	var failed bool
+	for !failed {
+		select {
+		case event := <-events.C:
+			if event.Err != nil {
+				failed = true
+				assert.Equal(t, "5.6.7.8", event.Socket.GetIp())
+			}
+		case <-ctx.Done():
+			t.Fatal("heartbeat failure not published")
+		}
+	}
+
+	for {
+		select {
+		case event := <-events.C:
+			if event.Err == nil && event.Socket.GetIp() == "5.6.7.8" {
+				assert.Equal(t, "5.6.7.8", heartbeater.Socket().GetIp())
+
+				heartbeater.Stop()
+				<-heartbeater.Done()
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("heartbeat not retried")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package shredstream_client
+
+import (
+	"github.com/weeaa/jito-go/pb"
+	"github.com/weeaa/jito-go/pkg"
+	"google.golang.org/grpc"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPublicIPURL answers with the public IP of the caller in plain text.
+	DefaultPublicIPURL = "https://ifconfig.me/ip"
+	// DefaultHeartbeatTTL is assumed when the block engine does not tell how long a heartbeat is valid.
+	DefaultHeartbeatTTL = 5 * time.Second
+)
+
+type Client struct {
+	// GrpcConn is the connection dialed by the constructor, it is closed once the Supervisor recreates it.
+	//
+	// Deprecated: use Supervisor.Conn, which returns the live connection.
+	GrpcConn   *grpc.ClientConn
+	Supervisor *pkg.ConnSupervisor // Owns the connection and recreates it when it dies.
+
+	Shredstream jito_pb.ShredstreamClient
+
+	Auth *pkg.AuthenticationService
+
+	lifecycle *pkg.Lifecycle
+}
+
+type HeartbeatConfig struct {
+	Regions []string // regions to receive shreds from, e.g. "amsterdam" or "ny".
+	Port    int64    // UDP port shreds are sent to.
+
+	// IP shreds are sent to. When empty, the public IP is looked up from PublicIPURL and checked again every IPCheckInterval.
+	IP              string
+	PublicIPURL     string        // defaults to DefaultPublicIPURL.
+	IPCheckInterval time.Duration // defaults to 5m.
+	HTTPClient      *http.Client  // used for the public IP lookups, defaults to http.DefaultClient.
+
+	RetryInterval time.Duration // delay before retrying a failed heartbeat, defaults to 1s.
+}
+
+// Heartbeater keeps the block engine sending shreds to a socket by heartbeating before the previous heartbeat expires.
+type Heartbeater struct {
+	client *Client
+	config HeartbeatConfig
+
+	mu      sync.RWMutex
+	socket  *jito_pb.Socket
+	ttl     time.Duration
+	last    time.Time
+	changed chan struct{}
+
+	cancel func()
+	done   chan struct{}
+}
@@ -7,8 +7,10 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/weeaa/jito-go"
 	"github.com/weeaa/jito-go/clients/searcher_client"
+	"github.com/weeaa/jito-go/pkg"
 	"log"
 	"os"
+	"time"
 )
 
 func main() {
@@ -33,13 +35,12 @@ func main() {
 
 	ctx := context.Background()
 
-	client, err := searcher_client.New(
+	client, err := searcher_client.NewWithOptions(
 		ctx,
-		jito_go.NewYork.BlockEngineURL,
-		rpc.New(rpcAddr),
-		rpc.New(rpc.MainNetBeta_RPC),
-		key,
-		nil,
+		pkg.WithEndpoint(jito_go.NewYork.BlockEngineURL),
+		pkg.WithRPCClients(rpc.New(rpc.MainNetBeta_RPC), rpc.New(rpcAddr)),
+		pkg.WithPrivateKey(key),
+		pkg.WithRequestTimeout(10*time.Second),
 	)
 	if err != nil {
 		log.Fatal(err)
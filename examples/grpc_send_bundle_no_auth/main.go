@@ -31,7 +31,7 @@ func main() {
 		jito_go.NewYork.BlockEngineURL,
 		rpc.New(rpcAddr),
 		rpc.New(rpc.MainNetBeta_RPC),
-		"IP:PORT:USERNAME:PASSWORD", // this is a placeholder value showcasing the proxy format you should use, but you may have this field empty
+		"socks5://USERNAME:PASSWORD@IP:PORT", // placeholder showcasing the proxy format (http://, https:// or socks5://), you may leave this field empty
 		nil,
 	)
 	if err != nil {
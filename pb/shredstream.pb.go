@@ -163,9 +163,9 @@ func file_shredstream_proto_rawDescGZIP() []byte {
 
 var file_shredstream_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_shredstream_proto_goTypes = []interface{}{
-	(*Heartbeat)(nil),         // 0: shredstream.Heartbeat
-	(*HeartbeatResponse)(nil), // 1: shredstream.HeartbeatResponse
-	(*Socket)(nil),            // 2: shared.Socket
+	(*HeartbeatShredStream)(nil), // 0: shredstream.Heartbeat
+	(*HeartbeatResponse)(nil),    // 1: shredstream.HeartbeatResponse
+	(*Socket)(nil),               // 2: shared.Socket
 }
 var file_shredstream_proto_depIdxs = []int32{
 	2, // 0: shredstream.Heartbeat.socket:type_name -> shared.Socket
@@ -186,7 +186,7 @@ func file_shredstream_proto_init() {
 	file_shared_proto_init()
 	if !protoimpl.UnsafeEnabled {
 		file_shredstream_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Heartbeat); i {
+			switch v := v.(*HeartbeatShredStream); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -0,0 +1,192 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"sync"
+	"time"
+)
+
+var ErrNoBlockhash = errors.New("no blockhash fetched yet")
+
+// Blockhash is a recent blockhash and the last block height at which transactions using it are accepted.
+type Blockhash struct {
+	Hash                 solana.Hash
+	LastValidBlockHeight uint64
+	Slot                 uint64
+	FetchedAt            time.Time
+}
+
+type BlockhashProviderConfig struct {
+	Commitment rpc.CommitmentType // defaults to rpc.CommitmentConfirmed.
+
+	// Interval between two polls, defaults to 1s. It is ignored while the slot subscription is alive.
+	Interval time.Duration
+
+	// WSClient, when set, refreshes the blockhash every SlotsPerRefresh slots instead of polling.
+	WSClient        *ws.Client
+	SlotsPerRefresh uint64 // defaults to 2.
+
+	// ExpiryMargin is the amount of blocks before LastValidBlockHeight at which a blockhash is considered expiring, defaults to 30.
+	ExpiryMargin uint64
+
+	Events *EventBus // receives an ErrorEvent when a refresh fails, may be nil.
+}
+
+// BlockhashProvider keeps the latest blockhash fresh in the background so it can be handed out without a network round trip.
+// It remembers the blockhashes it handed out to tell whether a transaction built with one of them is about to expire.
+type BlockhashProvider struct {
+	rpc    *rpc.Client
+	config BlockhashProviderConfig
+
+	mu          sync.RWMutex
+	latest      Blockhash
+	blockHeight uint64
+	seen        map[solana.Hash]uint64 // last valid block height of the blockhashes fetched so far.
+}
+
+func NewBlockhashProvider(rpcClient *rpc.Client, config BlockhashProviderConfig) *BlockhashProvider {
+	if config.Commitment == "" {
+		config.Commitment = rpc.CommitmentConfirmed
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.SlotsPerRefresh == 0 {
+		config.SlotsPerRefresh = 2
+	}
+	if config.ExpiryMargin == 0 {
+		config.ExpiryMargin = 30
+	}
+
+	return &BlockhashProvider{rpc: rpcClient, config: config, seen: make(map[solana.Hash]uint64)}
+}
+
+// Start fetches the first blockhash, then keeps refreshing it in the background until ctx is done.
+func (p *BlockhashProvider) Start(ctx context.Context) error {
+	if err := p.Refresh(ctx); err != nil {
+		return err
+	}
+
+	go p.Run(ctx)
+	return nil
+}
+
+// Run refreshes the blockhash until ctx is done, it is the blocking counterpart of Start.
+func (p *BlockhashProvider) Run(ctx context.Context) {
+	if p.config.WSClient != nil {
+		err := p.followSlots(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// keep serving fresh blockhashes by polling when the subscription dies
+		p.config.Events.Publish(ErrorEvent{Err: fmt.Errorf("blockhash slot subscription ended, polling instead: %w", err), Time: time.Now()})
+	}
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.refresh(ctx)
+		}
+	}
+}
+
+func (p *BlockhashProvider) followSlots(ctx context.Context) error {
+	sub, err := p.config.WSClient.SlotSubscribe()
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		slot, err := sub.Recv(ctx)
+		if err != nil {
+			return err
+		}
+
+		if slot.Slot%p.config.SlotsPerRefresh == 0 {
+			p.refresh(ctx)
+		}
+	}
+}
+
+func (p *BlockhashProvider) refresh(ctx context.Context) {
+	if err := p.Refresh(ctx); err != nil && ctx.Err() == nil {
+		p.config.Events.Publish(ErrorEvent{Err: err, Time: time.Now()})
+	}
+}
+
+// Refresh fetches the latest blockhash and the current block height right away.
+func (p *BlockhashProvider) Refresh(ctx context.Context) error {
+	latest, err := p.rpc.GetLatestBlockhash(ctx, p.config.Commitment)
+	if err != nil {
+		return fmt.Errorf("failed to get latest blockhash: %w", err)
+	}
+
+	height, err := p.rpc.GetBlockHeight(ctx, p.config.Commitment)
+	if err != nil {
+		return fmt.Errorf("failed to get block height: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latest = Blockhash{
+		Hash:                 latest.Value.Blockhash,
+		LastValidBlockHeight: latest.Value.LastValidBlockHeight,
+		Slot:                 latest.Context.Slot,
+		FetchedAt:            time.Now(),
+	}
+	p.blockHeight = max(p.blockHeight, height)
+	p.seen[p.latest.Hash] = p.latest.LastValidBlockHeight
+
+	for hash, lastValid := range p.seen {
+		if lastValid < p.blockHeight {
+			delete(p.seen, hash)
+		}
+	}
+
+	return nil
+}
+
+// Latest returns the most recent blockhash fetched.
+func (p *BlockhashProvider) Latest() (Blockhash, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.latest.FetchedAt.IsZero() {
+		return Blockhash{}, ErrNoBlockhash
+	}
+	return p.latest, nil
+}
+
+// BlockHeight returns the block height observed at the last refresh.
+func (p *BlockhashProvider) BlockHeight() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.blockHeight
+}
+
+// IsExpiring reports whether hash is within ExpiryMargin blocks of its last valid block height, or already expired.
+// Blockhashes not fetched by the provider are reported as expiring since their validity is unknown.
+func (p *BlockhashProvider) IsExpiring(hash solana.Hash) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	lastValid, ok := p.seen[hash]
+	return !ok || p.blockHeight+p.config.ExpiryMargin >= lastValid
+}
+
+// IsTransactionExpiring is IsExpiring applied to the recent blockhash of tx.
+func (p *BlockhashProvider) IsTransactionExpiring(tx *solana.Transaction) bool {
+	return p.IsExpiring(tx.Message.RecentBlockhash)
+}
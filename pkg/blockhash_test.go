@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBlockhashProvider(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hashes := []solana.Hash{{1}, {2}}
+	var height atomic.Uint64
+	height.Store(1000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result string
+		switch h := height.Load(); req.Method {
+		case "getLatestBlockhash":
+			result = fmt.Sprintf(`{"context":{"slot":%d},"value":{"blockhash":"%s","lastValidBlockHeight":%d}}`, h, hashes[(h/100)%2], h+150)
+		case "getBlockHeight":
+			result = fmt.Sprint(h)
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer server.Close()
+
+	provider := NewBlockhashProvider(rpc.New(server.URL), BlockhashProviderConfig{Interval: 10 * time.Millisecond})
+	_, err := provider.Latest()
+	assert.ErrorIs(t, err, ErrNoBlockhash)
+
+	if !assert.NoError(t, provider.Start(ctx)) {
+		t.FailNow()
+	}
+
+	latest, err := provider.Latest()
+	assert.NoError(t, err)
+	assert.Equal(t, hashes[0], latest.Hash)
+	assert.Equal(t, uint64(1150), latest.LastValidBlockHeight)
+	assert.False(t, provider.IsExpiring(hashes[0]))
+	assert.True(t, provider.IsExpiring(solana.Hash{}))
+
+	// the first blockhash gets close to its last valid block height while a new one is served
+	height.Store(1125)
+	assert.Eventually(t, func() bool {
+		latest, _ := provider.Latest()
+		return latest.Hash == hashes[1]
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, provider.IsExpiring(hashes[0]))
+	assert.False(t, provider.IsExpiring(hashes[1]))
+	assert.Equal(t, uint64(1125), provider.BlockHeight())
+}
@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/hashicorp/golang-lru/v2"
+	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"time"
+)
+
+// DefaultBundleDedupSize is the amount of bundle UUIDs remembered to drop bundles forwarded twice.
+const DefaultBundleDedupSize = 10_000
+
+// BundleTip sums the transfers of a bundle to the tip accounts.
+type BundleTip struct {
+	Tipper  solana.PublicKey // sender of the largest tip transfer.
+	Account solana.PublicKey // tip account receiving the largest tip transfer.
+	Amount  uint64           // lamports sent to all tip accounts.
+}
+
+// DecodedBundle is a bundle forwarded by the block engine with its transactions decoded.
+type DecodedBundle struct {
+	UUID      string
+	Packets   []Packet
+	Timestamp time.Time // timestamp of the bundle header, zero when it had none.
+	Tip       *BundleTip
+}
+
+// Transactions returns the transactions of the bundle in order.
+func (b DecodedBundle) Transactions() []*solana.Transaction {
+	txns := make([]*solana.Transaction, len(b.Packets))
+	for i, packet := range b.Packets {
+		txns[i] = packet.Transaction
+	}
+	return txns
+}
+
+// DecodeBundle decodes the packets of bundle and looks for transfers to tipAccounts, jito_go.MainnetTipAccounts when nil.
+// A bundle failing to decode is returned with the packets decoded successfully and the *PacketDecodeError of the others.
+func DecodeBundle(bundle *jito_pb.BundleUuid, tipAccounts []solana.PublicKey) (DecodedBundle, []error) {
+	header := bundle.GetBundle().GetHeader()
+	packets, errs := DecodePackets(header, bundle.GetBundle().GetPackets())
+
+	decoded := DecodedBundle{UUID: bundle.GetUuid(), Packets: packets}
+	if ts := header.GetTs(); ts != nil {
+		decoded.Timestamp = ts.AsTime()
+	}
+
+	if tip, ok := FindBundleTip(decoded.Transactions(), tipAccounts); ok {
+		decoded.Tip = &tip
+	}
+
+	return decoded, errs
+}
+
+// FindBundleTip returns the system program transfers of txns to tipAccounts, jito_go.MainnetTipAccounts when nil.
+// Only the static account keys are inspected, tip accounts loaded from address lookup tables are not seen.
+func FindBundleTip(txns []*solana.Transaction, tipAccounts []solana.PublicKey) (BundleTip, bool) {
+	if tipAccounts == nil {
+		tipAccounts = jito_go.MainnetTipAccounts
+	}
+
+	isTipAccount := make(map[solana.PublicKey]bool, len(tipAccounts))
+	for _, account := range tipAccounts {
+		isTipAccount[account] = true
+	}
+
+	var tip BundleTip
+	var largest uint64
+	found := false
+
+	for _, tx := range txns {
+		keys := tx.Message.AccountKeys
+		for _, inst := range tx.Message.Instructions {
+			if int(inst.ProgramIDIndex) >= len(keys) || !keys[inst.ProgramIDIndex].Equals(solana.SystemProgramID) {
+				continue
+			}
+
+			// system transfer: u32 instruction index 2 then u64 lamports, accounts are [from, to]
+			if len(inst.Data) != 12 || binary.LittleEndian.Uint32(inst.Data) != 2 || len(inst.Accounts) < 2 {
+				continue
+			}
+			if int(inst.Accounts[0]) >= len(keys) || int(inst.Accounts[1]) >= len(keys) {
+				continue
+			}
+
+			from, to := keys[inst.Accounts[0]], keys[inst.Accounts[1]]
+			if !isTipAccount[to] {
+				continue
+			}
+
+			lamports := binary.LittleEndian.Uint64(inst.Data[4:])
+			tip.Amount += lamports
+			if !found || lamports > largest {
+				tip.Tipper, tip.Account, largest = from, to, lamports
+			}
+			found = true
+		}
+	}
+
+	return tip, found
+}
+
+// BundleDeduplicator remembers the most recent bundle UUIDs, it is safe for concurrent use.
+type BundleDeduplicator struct {
+	seen *lru.Cache[string, struct{}]
+}
+
+// NewBundleDeduplicator remembers up to size UUIDs, DefaultBundleDedupSize when size is not positive.
+func NewBundleDeduplicator(size int) *BundleDeduplicator {
+	if size <= 0 {
+		size = DefaultBundleDedupSize
+	}
+
+	seen, _ := lru.New[string, struct{}](size)
+	return &BundleDeduplicator{seen: seen}
+}
+
+// Seen records uuid and reports whether it was already recorded.
+func (d *BundleDeduplicator) Seen(uuid string) bool {
+	found, _ := d.seen.ContainsOrAdd(uuid, struct{}{})
+	return found
+}
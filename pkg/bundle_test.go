@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestDecodeBundle(t *testing.T) {
+	tipper := solana.NewWallet()
+	tipTx, err := solana.NewTransaction([]solana.Instruction{
+		system.NewTransferInstruction(500, tipper.PublicKey(), jito_go.MainnetTipAccounts[1]).Build(),
+		system.NewTransferInstruction(1000, tipper.PublicKey(), jito_go.MainnetTipAccounts[0]).Build(),
+	}, solana.Hash{1}, solana.TransactionPayer(tipper.PublicKey()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = tipTx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &tipper.PrivateKey })
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	packets, err := ConvertBatchTransactionToProtobufPacket([]*solana.Transaction{newTestTransaction(t), tipTx})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	ts := time.Unix(1700000000, 0).UTC()
+	bundle, errs := DecodeBundle(&jito_pb.BundleUuid{
+		Uuid:   "uuid",
+		Bundle: &jito_pb.Bundle{Header: &jito_pb.Header{Ts: timestamppb.New(ts)}, Packets: packets},
+	}, nil)
+	assert.Empty(t, errs)
+	assert.Equal(t, "uuid", bundle.UUID)
+	assert.Equal(t, ts, bundle.Timestamp)
+	assert.Len(t, bundle.Transactions(), 2)
+
+	if assert.NotNil(t, bundle.Tip) {
+		assert.Equal(t, BundleTip{Tipper: tipper.PublicKey(), Account: jito_go.MainnetTipAccounts[0], Amount: 1500}, *bundle.Tip)
+	}
+
+	_, found := FindBundleTip(bundle.Transactions()[:1], nil)
+	assert.False(t, found)
+}
+
+func TestBundleDeduplicator(t *testing.T) {
+	dedup := NewBundleDeduplicator(2)
+	assert.False(t, dedup.Seen("a"))
+	assert.True(t, dedup.Seen("a"))
+	assert.False(t, dedup.Seen("b"))
+	assert.False(t, dedup.Seen("c"))
+	// the least recently used uuid was evicted
+	assert.False(t, dedup.Seen("a"))
+}
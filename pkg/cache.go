@@ -0,0 +1,162 @@
+package pkg
+
+import (
+	"context"
+	"github.com/weeaa/jito-go/pb"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs are the slow-changing reads cached by NewCache when CacheConfig.TTLs is nil.
+var DefaultCacheTTLs = map[string]time.Duration{
+	jito_pb.SearcherService_GetRegions_FullMethodName:                  5 * time.Minute,
+	jito_pb.SearcherService_GetTipAccounts_FullMethodName:              5 * time.Minute,
+	jito_pb.SearcherService_GetConnectedLeaders_FullMethodName:         10 * time.Second,
+	jito_pb.SearcherService_GetConnectedLeadersRegioned_FullMethodName: 10 * time.Second,
+	jito_pb.BlockEngineValidator_GetBlockBuilderFeeInfo_FullMethodName: time.Minute,
+}
+
+type CacheConfig struct {
+	// TTLs maps full method names to the lifetime of their responses, methods absent from it are not cached.
+	TTLs map[string]time.Duration
+
+	// RefreshAhead, when set, re-fetches an entry in the background once it is read less than RefreshAhead
+	// before expiring, so hot loops never wait on the network.
+	RefreshAhead time.Duration
+
+	// RefreshTimeout bounds the calls filling the cache, which outlive the callers waiting on them, and the
+	// background refreshes, defaults to 10s.
+	RefreshTimeout time.Duration
+}
+
+type cacheEntry struct {
+	reply   proto.Message
+	expires time.Time
+}
+
+// Cache is a read-through cache of unary responses keyed by method, target and request, so that a cache shared by
+// the clients of several regions never serves the reply of one region to another. Concurrent identical
+// calls missing the cache are collapsed into a single request, errors are never cached.
+type Cache struct {
+	config  CacheConfig
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func NewCache(config CacheConfig) *Cache {
+	if config.TTLs == nil {
+		config.TTLs = DefaultCacheTTLs
+	}
+	if config.RefreshTimeout <= 0 {
+		config.RefreshTimeout = 10 * time.Second
+	}
+
+	return &Cache{config: config, entries: make(map[string]cacheEntry)}
+}
+
+// Purge drops every entry, the next calls hit the network.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// Invalidate drops the entries of method, whatever their target.
+func (c *Cache) Invalidate(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, method+"\x00") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *Cache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, ok
+}
+
+// fetch invokes the call once for all concurrent callers of key and stores the reply, template gives the reply type.
+// Each caller waits until its own ctx is done, the call is detached from it so that a caller giving up does not fail
+// the others, it keeps its metadata, e.g. the authorization header.
+func (c *Cache) fetch(ctx context.Context, key, method string, ttl time.Duration, req any, template proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (proto.Message, error) {
+	ch := c.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.config.RefreshTimeout)
+		defer cancel()
+
+		fresh := template.ProtoReflect().New().Interface()
+		if err := invoker(ctx, method, req, fresh, cc, opts...); err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.entries[key] = cacheEntry{reply: fresh, expires: time.Now().Add(ttl)}
+		c.mu.Unlock()
+
+		return fresh, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(proto.Message), nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// UnaryInterceptor returns the interceptor serving the cached methods, see WithCache.
+func (c *Cache) UnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ttl, ok := c.config.TTLs[method]
+		reqMsg, isReq := req.(proto.Message)
+		replyMsg, isReply := reply.(proto.Message)
+		if !ok || ttl <= 0 || !isReq || !isReply {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(reqMsg)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		var target string
+		if cc != nil {
+			target = cc.Target()
+		}
+		key := method + "\x00" + target + "\x00" + string(encoded)
+
+		if entry, ok := c.get(key); ok {
+			if c.config.RefreshAhead > 0 && time.Until(entry.expires) < c.config.RefreshAhead {
+				go c.fetch(context.WithoutCancel(ctx), key, method, ttl, proto.Clone(reqMsg), entry.reply, cc, invoker, opts...)
+			}
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, entry.reply)
+			return nil
+		}
+
+		fresh, err := c.fetch(ctx, key, method, ttl, req, replyMsg, cc, invoker, opts...)
+		if err != nil {
+			return err
+		}
+		proto.Reset(replyMsg)
+		proto.Merge(replyMsg, fresh)
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	method := jito_pb.SearcherService_GetRegions_FullMethodName
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		<-release
+		n := calls.Add(1)
+		if regions, ok := reply.(*jito_pb.GetRegionsResponse); ok {
+			regions.CurrentRegion = string(rune('a' + n - 1))
+		}
+		return nil
+	}
+
+	cache := NewCache(CacheConfig{TTLs: map[string]time.Duration{method: 500 * time.Millisecond}, RefreshAhead: 490 * time.Millisecond})
+	interceptor := cache.UnaryInterceptor()
+	get := func() (string, error) {
+		reply := &jito_pb.GetRegionsResponse{}
+		err := interceptor(ctx, method, &jito_pb.GetRegionsRequest{}, reply, nil, invoker)
+		return reply.GetCurrentRegion(), err
+	}
+
+	// concurrent misses are collapsed into one call
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			region, err := get()
+			assert.NoError(t, err)
+			assert.Equal(t, "a", region)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// a hit close to the expiry serves the cached value and refreshes it in the background
+	time.Sleep(15 * time.Millisecond)
+	region, err := get()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", region)
+	assert.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, time.Millisecond)
+
+	region, _ = get()
+	assert.Equal(t, "b", region)
+
+	cache.Invalidate(method)
+	region, _ = get()
+	assert.Equal(t, "c", region)
+
+	// errors and methods without TTL are never cached
+	failing := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		calls.Add(1)
+		return errors.New("unavailable")
+	}
+	cache.Purge()
+	for range 2 {
+		assert.Error(t, interceptor(ctx, method, &jito_pb.GetRegionsRequest{}, &jito_pb.GetRegionsResponse{}, nil, failing))
+		assert.NoError(t, interceptor(ctx, jito_pb.SearcherService_SendBundle_FullMethodName, &jito_pb.SendBundleRequest{}, &jito_pb.SendBundleResponse{}, nil, invoker))
+	}
+	assert.Equal(t, int32(7), calls.Load())
+
+	// a caller giving up does not fail the others waiting on the same call
+	release = make(chan struct{})
+	cancelled, cancel := context.WithCancel(ctx)
+	chErr := make(chan error, 1)
+	go func() {
+		chErr <- interceptor(cancelled, method, &jito_pb.GetRegionsRequest{}, &jito_pb.GetRegionsResponse{}, nil, invoker)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	region, err = get()
+	assert.NoError(t, err)
+	assert.Equal(t, "h", region)
+	assert.Equal(t, codes.Canceled, status.Code(<-chErr))
+
+	// replies are cached per target
+	cache = NewCache(CacheConfig{TTLs: map[string]time.Duration{method: time.Minute}})
+	interceptor = cache.UnaryInterceptor()
+	regional := func(target string) string {
+		conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer conn.Close()
+
+		reply := &jito_pb.GetRegionsResponse{}
+		assert.NoError(t, interceptor(ctx, method, &jito_pb.GetRegionsRequest{}, reply, conn, func(_ context.Context, _ string, _, reply any, cc *grpc.ClientConn, _ ...grpc.CallOption) error {
+			reply.(*jito_pb.GetRegionsResponse).CurrentRegion = cc.Target()
+			return nil
+		}))
+		return reply.GetCurrentRegion()
+	}
+	assert.Equal(t, "passthrough:///amsterdam", regional("passthrough:///amsterdam"))
+	assert.Equal(t, "passthrough:///tokyo", regional("passthrough:///tokyo"))
+	assert.Equal(t, "passthrough:///amsterdam", regional("passthrough:///amsterdam"))
+
+	cache.Invalidate(method)
+	assert.Empty(t, cache.entries)
+}
@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/weeaa/jito-go/pb"
+)
+
+// BlockBuilderFeeInfo is the block builder a validator pays and its commission, in percent of the tips.
+type BlockBuilderFeeInfo struct {
+	BlockBuilder solana.PublicKey
+	Commission   uint64
+}
+
+// NewBlockBuilderFeeInfo parses the response of GetBlockBuilderFeeInfo.
+func NewBlockBuilderFeeInfo(resp *jito_pb.BlockBuilderFeeInfoResponse) (BlockBuilderFeeInfo, error) {
+	builder, err := solana.PublicKeyFromBase58(resp.GetPubkey())
+	if err != nil {
+		return BlockBuilderFeeInfo{}, fmt.Errorf("invalid block builder pubkey %q: %w", resp.GetPubkey(), err)
+	}
+	if resp.GetCommission() > 100 {
+		return BlockBuilderFeeInfo{}, fmt.Errorf("invalid block builder commission %d%%", resp.GetCommission())
+	}
+
+	return BlockBuilderFeeInfo{BlockBuilder: builder, Commission: resp.GetCommission()}, nil
+}
+
+// Share returns the lamports owed to the block builder out of tips, rounded down like the tip payment program does.
+func (f BlockBuilderFeeInfo) Share(tips ...uint64) uint64 {
+	var total uint64
+	for _, tip := range tips {
+		total += tip
+	}
+	return total/100*f.Commission + total%100*f.Commission/100
+}
+
+// PaymentInstruction transfers the share of tips owed to the block builder from the validator account.
+func (f BlockBuilderFeeInfo) PaymentInstruction(from solana.PublicKey, tips ...uint64) solana.Instruction {
+	return system.NewTransferInstruction(f.Share(tips...), from, f.BlockBuilder).Build()
+}
+
+// TipPaymentConfig is the config account of the tip payment program.
+type TipPaymentConfig struct {
+	TipReceiver  solana.PublicKey
+	BlockBuilder solana.PublicKey
+	Commission   uint64 // block builder commission in percent.
+}
+
+// TipPaymentConfigAddress returns the config account of the tip payment program programID.
+func TipPaymentConfigAddress(programID solana.PublicKey) (solana.PublicKey, error) {
+	address, _, err := solana.FindProgramAddress([][]byte{[]byte("CONFIG_ACCOUNT")}, programID)
+	return address, err
+}
+
+// TipPaymentAccounts returns the 8 tip accounts of the tip payment program programID.
+func TipPaymentAccounts(programID solana.PublicKey) ([]solana.PublicKey, error) {
+	accounts := make([]solana.PublicKey, 8)
+	for i := range accounts {
+		address, _, err := solana.FindProgramAddress([][]byte{[]byte(fmt.Sprintf("TIP_ACCOUNT_%d", i))}, programID)
+		if err != nil {
+			return nil, err
+		}
+		accounts[i] = address
+	}
+	return accounts, nil
+}
+
+// GetTipPaymentConfig fetches and decodes the config account of the tip payment program programID,
+// e.g. jito_go.MainnetTipPaymentProgram.
+func GetTipPaymentConfig(ctx context.Context, rpcClient *rpc.Client, programID solana.PublicKey) (*TipPaymentConfig, error) {
+	address, err := TipPaymentConfigAddress(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := rpcClient.GetAccountInfo(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tip payment config: %w", err)
+	}
+
+	// anchor discriminator, tip receiver, block builder then the commission
+	data := account.GetBinary()
+	if len(data) < 80 {
+		return nil, errors.New("tip payment config account is too short")
+	}
+
+	return &TipPaymentConfig{
+		TipReceiver:  solana.PublicKeyFromBytes(data[8:40]),
+		BlockBuilder: solana.PublicKeyFromBytes(data[40:72]),
+		Commission:   binary.LittleEndian.Uint64(data[72:80]),
+	}, nil
+}
+
+// NewChangeBlockBuilderInstruction builds the change_block_builder instruction of the tip payment program programID,
+// switching from the block builder of current to fee. The tips accrued so far are paid out using current.
+func NewChangeBlockBuilderInstruction(programID solana.PublicKey, current TipPaymentConfig, fee BlockBuilderFeeInfo, signer solana.PublicKey) (solana.Instruction, error) {
+	config, err := TipPaymentConfigAddress(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	tipAccounts, err := TipPaymentAccounts(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(config).WRITE(),
+		solana.Meta(current.TipReceiver).WRITE(),
+		solana.Meta(current.BlockBuilder).WRITE(),
+		solana.Meta(fee.BlockBuilder).WRITE(),
+	}
+	for _, account := range tipAccounts {
+		accounts = append(accounts, solana.Meta(account).WRITE())
+	}
+	accounts = append(accounts, solana.Meta(signer).WRITE().SIGNER())
+
+	discriminator := sha256.Sum256([]byte("global:change_block_builder"))
+	data := binary.LittleEndian.AppendUint64(discriminator[:8:8], fee.Commission)
+
+	return solana.NewInstruction(programID, accounts, data), nil
+}
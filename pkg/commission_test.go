@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"testing"
+)
+
+func TestBlockBuilderFeeInfo(t *testing.T) {
+	builder := solana.NewWallet().PublicKey()
+
+	_, err := NewBlockBuilderFeeInfo(&jito_pb.BlockBuilderFeeInfoResponse{Pubkey: "invalid", Commission: 5})
+	assert.Error(t, err)
+	_, err = NewBlockBuilderFeeInfo(&jito_pb.BlockBuilderFeeInfoResponse{Pubkey: builder.String(), Commission: 101})
+	assert.Error(t, err)
+
+	fee, err := NewBlockBuilderFeeInfo(&jito_pb.BlockBuilderFeeInfoResponse{Pubkey: builder.String(), Commission: 5})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, uint64(50), fee.Share(600, 400))
+	assert.Equal(t, uint64(0), fee.Share(19))
+	assert.Equal(t, uint64(922337203685477580), fee.Share(1<<64-1))
+
+	validator := solana.NewWallet().PublicKey()
+	payment := fee.PaymentInstruction(validator, 1000)
+	assert.Equal(t, solana.SystemProgramID, payment.ProgramID())
+	assert.Equal(t, builder, payment.Accounts()[1].PublicKey)
+}
+
+func TestChangeBlockBuilderInstruction(t *testing.T) {
+	tipAccounts, err := TipPaymentAccounts(jito_go.MainnetTipPaymentProgram)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.ElementsMatch(t, jito_go.MainnetTipAccounts, tipAccounts)
+
+	current := TipPaymentConfig{TipReceiver: solana.NewWallet().PublicKey(), BlockBuilder: solana.NewWallet().PublicKey(), Commission: 5}
+	fee := BlockBuilderFeeInfo{BlockBuilder: solana.NewWallet().PublicKey(), Commission: 10}
+	signer := solana.NewWallet().PublicKey()
+
+	inst, err := NewChangeBlockBuilderInstruction(jito_go.MainnetTipPaymentProgram, current, fee, signer)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	accounts := inst.Accounts()
+	if assert.Len(t, accounts, 13) {
+		assert.Equal(t, current.BlockBuilder, accounts[2].PublicKey)
+		assert.Equal(t, fee.BlockBuilder, accounts[3].PublicKey)
+		assert.True(t, accounts[12].IsSigner)
+	}
+
+	data, err := inst.Data()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{10, 0, 0, 0, 0, 0, 0, 0}, data[8:])
+}
@@ -0,0 +1,363 @@
+package pkg
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/reedsolomon"
+	"sync"
+)
+
+// ErrSlotCorrupted is returned by SlotDeshredder once a batch of its entries could not be decoded,
+// the entry indexes of the rest of the slot being unknown.
+var ErrSlotCorrupted = errors.New("slot corrupted")
+
+// SlotEntry is an entry decoded from the shreds of a slot, Index is its position among the entries of the slot.
+type SlotEntry struct {
+	Slot  uint64
+	Index uint64
+	Entry
+}
+
+// SlotTransaction is a transaction of the entry EntryIndex of a slot.
+type SlotTransaction struct {
+	Slot       uint64
+	EntryIndex uint64
+	*solana.Transaction
+}
+
+type fecSet struct {
+	numData   int // 0 until a coding shred tells the layout of the erasure set.
+	numCoding int
+	legacy    bool
+	shards    map[int][]byte // erasure shards by position, data shreds first.
+	recovered bool
+}
+
+type dataShred struct {
+	data  []byte
+	flags ShredFlags
+}
+
+// SlotDeshredder reassembles the entries of a single slot from its shreds, recovering missing data shreds from
+// coding shreds with Reed-Solomon. Shreds are trusted: their signature and merkle proof are not verified.
+// It is not safe for concurrent use.
+type SlotDeshredder struct {
+	slot    uint64
+	sets    map[uint32]*fecSet
+	data    map[uint32]dataShred
+	next    uint32 // index of the first data shred of the next batch to decode.
+	entries uint64 // entries decoded so far.
+	last    bool   // the last data shred of the slot was decoded.
+	err     error
+}
+
+func NewSlotDeshredder(slot uint64) *SlotDeshredder {
+	return &SlotDeshredder{slot: slot, sets: make(map[uint32]*fecSet), data: make(map[uint32]dataShred)}
+}
+
+// Complete reports whether every entry of the slot was decoded.
+func (d *SlotDeshredder) Complete() bool {
+	return d.last
+}
+
+// Add records shred and returns the entries it completed, in order. Shreds of another slot or inconsistent with
+// the shreds added so far are rejected with ErrInvalidShred.
+func (d *SlotDeshredder) Add(shred Shred) ([]SlotEntry, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.last {
+		return nil, nil
+	}
+	if shred.Slot != d.slot {
+		return nil, fmt.Errorf("%w: slot %d added to the deshredder of slot %d", ErrInvalidShred, shred.Slot, d.slot)
+	}
+
+	set, ok := d.sets[shred.FECSetIndex]
+	if !ok {
+		set = &fecSet{legacy: shred.Variant.IsLegacy(), shards: make(map[int][]byte)}
+		d.sets[shred.FECSetIndex] = set
+	}
+	if set.legacy != shred.Variant.IsLegacy() {
+		return nil, fmt.Errorf("%w: legacy and merkle shreds in fec set %d", ErrInvalidShred, shred.FECSetIndex)
+	}
+
+	if shred.Code != nil {
+		if err := d.addCode(set, shred); err != nil {
+			return nil, err
+		}
+	} else {
+		position := int(shred.Index - shred.FECSetIndex)
+		if (set.numData > 0 && position >= set.numData) || (!set.legacy && position >= MaxDataShredsPerFECSet) {
+			return nil, fmt.Errorf("%w: data shred %d out of fec set %d", ErrInvalidShred, shred.Index, shred.FECSetIndex)
+		}
+		set.shards[position] = erasureShard(shred)
+		d.data[shred.Index] = dataShred{data: shred.DataPayload(), flags: shred.Data.Flags}
+	}
+
+	if err := d.recover(shred.FECSetIndex, set); err != nil {
+		return nil, err
+	}
+	return d.decode()
+}
+
+func (d *SlotDeshredder) addCode(set *fecSet, shred Shred) error {
+	numData, numCoding := int(shred.Code.NumDataShreds), int(shred.Code.NumCodingShreds)
+	if set.numData == 0 {
+		set.numData, set.numCoding = numData, numCoding
+
+		// data shreds added before the layout was known may lie beyond the set, they would never be recovered
+		for position := range set.shards {
+			if position >= numData {
+				delete(set.shards, position)
+				delete(d.data, shred.FECSetIndex+uint32(position))
+			}
+		}
+	} else if set.numData != numData || set.numCoding != numCoding {
+		return fmt.Errorf("%w: fec set %d is %d:%d and %d:%d", ErrInvalidShred, shred.FECSetIndex, set.numData, set.numCoding, numData, numCoding)
+	}
+
+	set.shards[set.numData+int(shred.Code.Position)] = erasureShard(shred)
+	return nil
+}
+
+// recover rebuilds the missing data shreds of set once enough shreds of it were received.
+func (d *SlotDeshredder) recover(fecSetIndex uint32, set *fecSet) error {
+	if set.recovered || set.numData == 0 || len(set.shards) < set.numData {
+		return nil
+	}
+
+	// shards whose size differs from most of the set come from inconsistent shreds, they are evicted so that the
+	// set can still be recovered from the others
+	sizes := make(map[int]int)
+	for _, shard := range set.shards {
+		sizes[len(shard)]++
+	}
+	size := 0
+	for n, count := range sizes {
+		if count > sizes[size] || (count == sizes[size] && n > size) {
+			size = n
+		}
+	}
+	for position, shard := range set.shards {
+		if len(shard) != size {
+			delete(set.shards, position)
+			if position < set.numData {
+				delete(d.data, fecSetIndex+uint32(position))
+			}
+		}
+	}
+	if len(set.shards) < set.numData {
+		return nil
+	}
+
+	missing := false
+	shards := make([][]byte, set.numData+set.numCoding)
+	for position, shard := range set.shards {
+		if position >= len(shards) {
+			return fmt.Errorf("%w: data shred %d out of fec set %d", ErrInvalidShred, fecSetIndex+uint32(position), fecSetIndex)
+		}
+		shards[position] = shard
+	}
+	for position := range set.numData {
+		missing = missing || shards[position] == nil
+	}
+
+	if !missing {
+		set.recovered = true
+		return nil
+	}
+
+	encoder, err := erasureEncoder(set.numData, set.numCoding)
+	if err != nil {
+		return err
+	}
+	if err = encoder.ReconstructData(shards); err != nil {
+		return fmt.Errorf("failed to recover fec set %d of slot %d: %w", fecSetIndex, d.slot, err)
+	}
+	set.recovered = true
+
+	// the signature is not erasure coded, headers start at the beginning of merkle shards
+	offset := 0
+	if !set.legacy {
+		offset = SizeOfShredSignature
+	}
+
+	for position := range set.numData {
+		index := fecSetIndex + uint32(position)
+		if _, ok := d.data[index]; ok || index < d.next {
+			continue
+		}
+
+		shard := shards[position]
+		size := int(binary.LittleEndian.Uint16(shard[86-offset:]))
+		if size < SizeOfDataShredHeaders || size-offset > len(shard) {
+			return fmt.Errorf("%w: recovered data shred %d has a size of %d", ErrInvalidShred, index, size)
+		}
+		d.data[index] = dataShred{data: shard[SizeOfDataShredHeaders-offset : size-offset], flags: ShredFlags(shard[85-offset])}
+	}
+	return nil
+}
+
+// decode decodes the batches of entries whose data shreds were all received or recovered.
+func (d *SlotDeshredder) decode() ([]SlotEntry, error) {
+	var decoded []SlotEntry
+	for !d.last {
+		end := d.next
+		for {
+			shred, ok := d.data[end]
+			if !ok {
+				return decoded, nil
+			}
+			if shred.flags.DataComplete() {
+				break
+			}
+			end++
+		}
+
+		last := d.data[end].flags.LastInSlot()
+		var batch []byte
+		for index := d.next; index <= end; index++ {
+			batch = append(batch, d.data[index].data...)
+			delete(d.data, index)
+		}
+
+		entries, err := DecodeEntries(batch)
+		if err != nil {
+			d.err = fmt.Errorf("%w: slot %d, shreds %d to %d: %w", ErrSlotCorrupted, d.slot, d.next, end, err)
+			return decoded, d.err
+		}
+
+		for _, entry := range entries {
+			decoded = append(decoded, SlotEntry{Slot: d.slot, Index: d.entries, Entry: entry})
+			d.entries++
+		}
+
+		d.last = last
+		d.next = end + 1
+	}
+	return decoded, nil
+}
+
+// erasureShard returns the part of shred which is erasure coded.
+func erasureShard(shred Shred) []byte {
+	v := shred.Variant
+	switch {
+	case v == ShredVariantLegacyData:
+		shard := make([]byte, v.Capacity()+SizeOfDataShredHeaders)
+		copy(shard, shred.Payload)
+		return shard
+	case v == ShredVariantLegacyCode:
+		return shred.Payload[SizeOfCodingShredHeaders:]
+	}
+
+	end := v.Capacity()
+	if v.Chained() {
+		end += SizeOfMerkleRoot
+	}
+	if shred.Code != nil {
+		return shred.Payload[SizeOfCodingShredHeaders : SizeOfCodingShredHeaders+end]
+	}
+	return shred.Payload[SizeOfShredSignature : SizeOfDataShredHeaders+end]
+}
+
+var erasureEncoders sync.Map // [2]int -> reedsolomon.Encoder
+
+func erasureEncoder(numData, numCoding int) (reedsolomon.Encoder, error) {
+	key := [2]int{numData, numCoding}
+	if encoder, ok := erasureEncoders.Load(key); ok {
+		return encoder.(reedsolomon.Encoder), nil
+	}
+
+	encoder, err := reedsolomon.New(numData, numCoding)
+	if err != nil {
+		return nil, err
+	}
+	erasureEncoders.Store(key, encoder)
+	return encoder, nil
+}
+
+// Deshred reassembles the entries of every slot streamed on slots, e.g. from ShredReceiver.Slots, each slot being
+// deshredded on its own goroutine. Entries are delivered in order within a slot, errors are dropped when the error
+// channel is full. Both channels are closed once slots is closed and drained, or ctx is done.
+func Deshred(ctx context.Context, slots <-chan SlotShreds) (<-chan SlotEntry, <-chan error) {
+	chEntry := make(chan SlotEntry)
+	chErr := make(chan error, 16)
+
+	var wg sync.WaitGroup
+	deshred := func(slot SlotShreds) {
+		defer wg.Done()
+
+		d := NewSlotDeshredder(slot.Slot)
+		for shred := range slot.Shreds {
+			entries, err := d.Add(shred)
+			if err != nil && !errors.Is(err, ErrSlotCorrupted) {
+				DispatchError(chErr, err)
+			}
+
+			for _, entry := range entries {
+				select {
+				case chEntry <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			// the rest of a corrupted slot cannot be decoded, it is reported once and its shreds are discarded
+			if errors.Is(err, ErrSlotCorrupted) {
+				DispatchError(chErr, err)
+				for range slot.Shreds {
+				}
+				return
+			}
+		}
+	}
+
+	go func() {
+		defer close(chErr)
+		defer close(chEntry)
+		defer wg.Wait()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case slot, ok := <-slots:
+				if !ok {
+					return
+				}
+				wg.Add(1)
+				go deshred(slot)
+			}
+		}
+	}()
+
+	return chEntry, chErr
+}
+
+// DeshredTransactions is Deshred streaming the transactions of the entries, ticks being skipped.
+func DeshredTransactions(ctx context.Context, slots <-chan SlotShreds) (<-chan SlotTransaction, <-chan error) {
+	entries, chErr := Deshred(ctx, slots)
+	chTx := make(chan SlotTransaction)
+
+	go func() {
+		defer close(chTx)
+		for entry := range entries {
+			for _, tx := range entry.Transactions {
+				select {
+				case chTx <- SlotTransaction{Slot: entry.Slot, EntryIndex: entry.Index, Transaction: tx}:
+				case <-ctx.Done():
+					// drain so that Deshred releases its goroutines
+					for range entries {
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	return chTx, chErr
+}
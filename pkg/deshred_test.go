@@ -0,0 +1,299 @@
+package pkg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/klauspost/reedsolomon"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// newTestFECSet shreds a batch of entries into an erasure set of variant, its last data shred being flagged with flags.
+func newTestFECSet(t *testing.T, data, code ShredVariant, slot uint64, fecSetIndex uint32, numCoding int, flags ShredFlags, batch []byte) (dataShreds, codeShreds []Shred) {
+	capacity := data.Capacity()
+	for len(batch) > 0 || len(dataShreds) == 0 {
+		n := min(len(batch), capacity)
+		shredFlags := ShredFlags(0)
+		if n == len(batch) {
+			shredFlags = flags
+		}
+
+		shred, err := ParseShred(newTestDataShred(data, slot, fecSetIndex+uint32(len(dataShreds)), fecSetIndex, shredFlags, batch[:n]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dataShreds = append(dataShreds, shred)
+		batch = batch[n:]
+	}
+
+	shards := make([][]byte, len(dataShreds)+numCoding)
+	for i, shred := range dataShreds {
+		shards[i] = append([]byte(nil), erasureShard(shred)...)
+	}
+	for i := len(dataShreds); i < len(shards); i++ {
+		shards[i] = make([]byte, len(shards[0]))
+	}
+
+	encoder, err := reedsolomon.New(len(dataShreds), numCoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = encoder.Encode(shards); err != nil {
+		t.Fatal(err)
+	}
+
+	for position := range numCoding {
+		index := fecSetIndex + uint32(position)
+		payload := newTestCodeShred(code, slot, index, fecSetIndex, uint16(len(dataShreds)), uint16(numCoding), uint16(position), shards[len(dataShreds)+position])
+		shred, err := ParseShred(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codeShreds = append(codeShreds, shred)
+	}
+	return dataShreds, codeShreds
+}
+
+func newTestEntries(t *testing.T) []Entry {
+	tx := newTestTransaction(t)
+	txs := make([]*solana.Transaction, 12)
+	for i := range txs {
+		txs[i] = tx
+	}
+	return []Entry{
+		{NumHashes: 12500, Hash: solana.Hash{1}, Transactions: []*solana.Transaction{}},
+		{NumHashes: 40, Hash: solana.Hash{2}, Transactions: txs},
+		{NumHashes: 1, Hash: solana.Hash{3}, Transactions: txs[:1]},
+	}
+}
+
+func TestSlotDeshredder(t *testing.T) {
+	entries := newTestEntries(t)
+	tick := Entry{NumHashes: 12500, Hash: solana.Hash{4}, Transactions: []*solana.Transaction{}}
+
+	variants := [][2]ShredVariant{{0x86, 0x46}, {0x96, 0x66}, {0xB6, 0x76}, {ShredVariantLegacyData, ShredVariantLegacyCode}}
+	for _, v := range variants {
+		firstData, firstCode := newTestFECSet(t, v[0], v[1], 42, 0, 4, ShredFlagDataComplete, encodeTestEntries(t, entries...))
+		lastData, lastCode := newTestFECSet(t, v[0], v[1], 42, uint32(len(firstData)), 1, ShredFlagLastInSlot, encodeTestEntries(t, tick))
+
+		d := NewSlotDeshredder(42)
+
+		// the second batch cannot be decoded before the first one
+		decoded, err := d.Add(lastCode[0])
+		assert.NoError(t, err, v[0].String())
+		assert.Empty(t, decoded)
+
+		// the first and last data shreds of the first set are lost
+		for _, shred := range append(firstData[1:len(firstData)-1], firstCode[2]) {
+			decoded, err = d.Add(shred)
+			assert.NoError(t, err, v[0].String())
+			assert.Empty(t, decoded)
+		}
+		assert.False(t, d.Complete())
+
+		// one more shred of the set allows to recover the missing data shreds
+		decoded, err = d.Add(firstCode[0])
+		if !assert.NoError(t, err, v[0].String()) || !assert.Len(t, decoded, 4, v[0].String()) {
+			continue
+		}
+		for i, entry := range decoded {
+			assert.Equal(t, uint64(42), entry.Slot)
+			assert.Equal(t, uint64(i), entry.Index)
+		}
+		assert.Equal(t, entries[0], decoded[0].Entry)
+		assert.Equal(t, entries[1].Hash, decoded[1].Hash)
+		assert.Len(t, decoded[1].Transactions, 12)
+		assert.Equal(t, entries[2].Transactions[0].Signatures, decoded[2].Transactions[0].Signatures)
+		assert.Equal(t, tick, decoded[3].Entry)
+		assert.True(t, d.Complete())
+
+		decoded, err = d.Add(lastData[0])
+		assert.NoError(t, err)
+		assert.Empty(t, decoded)
+	}
+
+	d := NewSlotDeshredder(42)
+	_, err := d.Add(Shred{Slot: 43})
+	assert.ErrorIs(t, err, ErrInvalidShred)
+
+	// a shard of another size is evicted, the set is recovered from the other shreds
+	sizedData, sizedCode := newTestFECSet(t, 0x86, 0x46, 42, 0, 4, ShredFlagLastInSlot, encodeTestEntries(t, entries...))
+	bad, err := ParseShred(newTestCodeShred(0x47, 42, 3, 0, uint16(len(sizedData)), 4, 3, nil))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	sized := NewSlotDeshredder(42)
+	for _, shred := range append([]Shred{bad}, sizedData[1:]...) {
+		_, err = sized.Add(shred)
+		assert.NoError(t, err)
+	}
+	decoded, err := sized.Add(sizedCode[0])
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 3)
+	assert.True(t, sized.Complete())
+
+	// merkle data shreds beyond the largest erasure set are rejected
+	beyond, err := ParseShred(newTestDataShred(0x86, 42, MaxDataShredsPerFECSet, 0, 0, []byte("beyond")))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = d.Add(beyond)
+	assert.ErrorIs(t, err, ErrInvalidShred)
+
+	// legacy data shreds beyond their erasure set are dropped once its layout is known, the set is still recovered
+	legacyData, legacyCode := newTestFECSet(t, ShredVariantLegacyData, ShredVariantLegacyCode, 42, 0, 1, ShredFlagLastInSlot, encodeTestEntries(t, tick))
+	beyond, err = ParseShred(newTestDataShred(ShredVariantLegacyData, 42, uint32(len(legacyData)+len(legacyCode)), 0, 0, []byte("beyond")))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	legacy := NewSlotDeshredder(42)
+	_, err = legacy.Add(beyond)
+	assert.NoError(t, err)
+	decoded, err = legacy.Add(legacyCode[0])
+	assert.NoError(t, err)
+	if assert.Len(t, decoded, 1) {
+		assert.Equal(t, tick, decoded[0].Entry)
+	}
+	assert.True(t, legacy.Complete())
+
+	// entries which cannot be decoded corrupt the rest of the slot
+	corrupted, _ := newTestFECSet(t, 0x86, 0x46, 42, 0, 1, ShredFlagDataComplete, []byte("not entries"))
+	_, err = d.Add(corrupted[0])
+	assert.ErrorIs(t, err, ErrSlotCorrupted)
+	_, err = d.Add(corrupted[0])
+	assert.ErrorIs(t, err, ErrSlotCorrupted)
+}
+
+func TestSlotDeshredderTestdata(t *testing.T) {
+	hash := func(seed string) solana.Hash {
+		return sha256.Sum256([]byte(seed))
+	}
+	payer := func(i int) solana.PublicKey {
+		seed := sha256.Sum256([]byte(fmt.Sprintf("payer %d", i)))
+		return solana.PublicKeyFromBytes(ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey))
+	}
+	check := func(entries []SlotEntry, want []Entry, firstPayer int) {
+		if !assert.Len(t, entries, len(want)) {
+			t.FailNow()
+		}
+		for i, entry := range entries {
+			assert.Equal(t, uint64(i), entry.Index)
+			assert.Equal(t, want[i].NumHashes, entry.NumHashes)
+			assert.Equal(t, want[i].Hash, entry.Hash)
+			if !assert.Len(t, entry.Transactions, len(want[i].Transactions)) {
+				continue
+			}
+			for _, tx := range entry.Transactions {
+				assert.Equal(t, payer(firstPayer), tx.Message.AccountKeys[0])
+				assert.NoError(t, tx.VerifySignatures())
+				firstPayer++
+			}
+		}
+	}
+	txs := func(n int) []*solana.Transaction {
+		return make([]*solana.Transaction, n)
+	}
+
+	// two data shreds of the chained set and every data shred of the resigned set are recovered from coding
+	// shreds
+	chained, resigned := loadTestShreds(t, "merkle_chained"), loadTestShreds(t, "merkle_chained_resigned")
+	d := NewSlotDeshredder(310_000_000)
+	var entries []SlotEntry
+	for _, shred := range append(append(chained[4:], chained[:2]...), resigned[3:]...) {
+		decoded, err := d.Add(shred)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		entries = append(entries, decoded...)
+	}
+	assert.True(t, d.Complete())
+	check(entries, []Entry{
+		{NumHashes: 12500, Hash: hash("tick 0")},
+		{NumHashes: 1, Hash: hash("entry 1"), Transactions: txs(12)},
+		{NumHashes: 1, Hash: hash("entry 2"), Transactions: txs(13)},
+		{NumHashes: 1, Hash: hash("entry 3"), Transactions: txs(5)},
+		{NumHashes: 1, Hash: hash("entry 4"), Transactions: txs(10)},
+		{NumHashes: 12500, Hash: hash("tick 1")},
+		{NumHashes: 12500, Hash: hash("tick 2")},
+	}, 0)
+
+	legacy := loadTestShreds(t, "legacy")
+	d = NewSlotDeshredder(150_000_000)
+	entries = nil
+	for _, shred := range []Shred{legacy[0], legacy[2], legacy[5]} {
+		decoded, err := d.Add(shred)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		entries = append(entries, decoded...)
+	}
+	assert.True(t, d.Complete())
+	check(entries, []Entry{
+		{NumHashes: 1, Hash: hash("legacy entry 0"), Transactions: txs(10)},
+		{NumHashes: 12500, Hash: hash("legacy tick 0")},
+	}, 40)
+}
+
+func TestDeshred(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entries := newTestEntries(t)
+	slots := make(chan SlotShreds, 2)
+	for _, slot := range []uint64{7, 8} {
+		data, code := newTestFECSet(t, 0x86, 0x46, slot, 0, 2, ShredFlagLastInSlot, encodeTestEntries(t, entries...))
+		shreds := make(chan Shred, 8)
+		for _, shred := range append(data[1:], code...) {
+			shreds <- shred
+		}
+		close(shreds)
+		slots <- SlotShreds{Slot: slot, Shreds: shreds}
+	}
+	close(slots)
+
+	chEntry, chErr := Deshred(ctx, slots)
+
+	received := make(map[uint64][]uint64)
+	for entry := range chEntry {
+		received[entry.Slot] = append(received[entry.Slot], entry.Index)
+	}
+	assert.Equal(t, map[uint64][]uint64{7: {0, 1, 2}, 8: {0, 1, 2}}, received)
+
+	_, ok := <-chErr
+	assert.False(t, ok)
+}
+
+func TestDeshredTransactions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entries := newTestEntries(t)
+	data, _ := newTestFECSet(t, 0x86, 0x46, 9, 0, 1, ShredFlagLastInSlot, encodeTestEntries(t, entries...))
+	shreds := make(chan Shred, len(data))
+	for _, shred := range data {
+		shreds <- shred
+	}
+	close(shreds)
+
+	slots := make(chan SlotShreds, 1)
+	slots <- SlotShreds{Slot: 9, Shreds: shreds}
+	close(slots)
+
+	chTx, _ := DeshredTransactions(ctx, slots)
+
+	var txs []SlotTransaction
+	for tx := range chTx {
+		txs = append(txs, tx)
+	}
+	if !assert.Len(t, txs, 13) {
+		t.FailNow()
+	}
+	assert.Equal(t, uint64(9), txs[0].Slot)
+	assert.Equal(t, uint64(1), txs[0].EntryIndex)
+	assert.Equal(t, uint64(2), txs[12].EntryIndex)
+	assert.Equal(t, entries[2].Transactions[0].Signatures, txs[12].Signatures)
+}
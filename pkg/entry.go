@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Entry is a PoH entry, ticks are entries without transactions.
+type Entry struct {
+	NumHashes    uint64
+	Hash         solana.Hash
+	Transactions []*solana.Transaction
+}
+
+// DecodeEntries decodes the bincode serialized entries carried by a batch of data shreds.
+func DecodeEntries(data []byte) ([]Entry, error) {
+	decoder := bin.NewBinDecoder(data)
+
+	count, err := decoder.ReadUint64(binary.LittleEndian)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entry count: %w", err)
+	}
+	// an entry takes 48 bytes at least, which bounds the allocation for corrupted counts
+	if count > uint64(decoder.Remaining()/48) {
+		return nil, fmt.Errorf("%d entries do not fit in %d bytes", count, decoder.Remaining())
+	}
+
+	entries := make([]Entry, count)
+	for i := range entries {
+		if entries[i], err = decodeEntry(decoder); err != nil {
+			return nil, fmt.Errorf("failed to decode entry %d: %w", i, err)
+		}
+	}
+	return entries, nil
+}
+
+func decodeEntry(decoder *bin.Decoder) (Entry, error) {
+	var entry Entry
+	var err error
+
+	if entry.NumHashes, err = decoder.ReadUint64(binary.LittleEndian); err != nil {
+		return Entry{}, err
+	}
+
+	hash, err := decoder.ReadNBytes(32)
+	if err != nil {
+		return Entry{}, err
+	}
+	copy(entry.Hash[:], hash)
+
+	count, err := decoder.ReadUint64(binary.LittleEndian)
+	if err != nil {
+		return Entry{}, err
+	}
+	// a transaction takes 64 bytes of signature at least
+	if count > uint64(decoder.Remaining()/64) {
+		return Entry{}, fmt.Errorf("%d transactions do not fit in %d bytes", count, decoder.Remaining())
+	}
+
+	entry.Transactions = make([]*solana.Transaction, count)
+	for i := range entry.Transactions {
+		if entry.Transactions[i], err = solana.TransactionFromDecoder(decoder); err != nil {
+			return Entry{}, fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+	}
+	return entry, nil
+}
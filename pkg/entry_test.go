@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// encodeTestEntries serializes entries the way leaders do before shredding them.
+func encodeTestEntries(t *testing.T, entries ...Entry) []byte {
+	data := binary.LittleEndian.AppendUint64(nil, uint64(len(entries)))
+	for _, entry := range entries {
+		data = binary.LittleEndian.AppendUint64(data, entry.NumHashes)
+		data = append(data, entry.Hash[:]...)
+		data = binary.LittleEndian.AppendUint64(data, uint64(len(entry.Transactions)))
+		for _, tx := range entry.Transactions {
+			raw, err := tx.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, raw...)
+		}
+	}
+	return data
+}
+
+func TestDecodeEntries(t *testing.T) {
+	tx := newTestTransaction(t)
+	tick := Entry{NumHashes: 12500, Hash: solana.Hash{7}, Transactions: []*solana.Transaction{}}
+	data := encodeTestEntries(t, tick, Entry{NumHashes: 3, Hash: solana.Hash{8}, Transactions: []*solana.Transaction{tx, tx}})
+
+	entries, err := DecodeEntries(data)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, entries, 2)
+	assert.Equal(t, tick, entries[0])
+	assert.Equal(t, uint64(3), entries[1].NumHashes)
+	assert.Equal(t, solana.Hash{8}, entries[1].Hash)
+	if assert.Len(t, entries[1].Transactions, 2) {
+		assert.Equal(t, tx.Signatures, entries[1].Transactions[1].Signatures)
+		assert.Equal(t, tx.Message.AccountKeys, entries[1].Transactions[1].Message.AccountKeys)
+	}
+
+	_, err = DecodeEntries(data[:len(data)-10])
+	assert.Error(t, err)
+
+	// a corrupted count is rejected before allocating
+	_, err = DecodeEntries(binary.LittleEndian.AppendUint64(nil, 1<<60))
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package pkg
+
+import (
+	"github.com/weeaa/jito-go/pb"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is implemented by every event published on an EventBus:
+// ConnStateEvent, ReconnectEvent, TokenEvent, StreamEvent, DroppedEvent, BundleResultEvent, HeartbeatEvent and ErrorEvent.
+type Event interface {
+	EventTime() time.Time
+}
+
+func (e ConnStateEvent) EventTime() time.Time { return e.Time }
+func (e StreamEvent) EventTime() time.Time    { return e.Time }
+
+// ReconnectEvent is published once a ConnSupervisor has recreated its connection.
+type ReconnectEvent struct {
+	Target string
+	Time   time.Time
+}
+
+func (e ReconnectEvent) EventTime() time.Time { return e.Time }
+
+// TokenEvent is published after every access token refresh, Err is set when the refresh failed.
+type TokenEvent struct {
+	ExpiresAt time.Time // expiry of the new access token.
+	Err       error
+	Time      time.Time
+}
+
+func (e TokenEvent) EventTime() time.Time { return e.Time }
+
+// DroppedEvent is published when a stream drops a message because its consumer is too slow.
+type DroppedEvent struct {
+	Stream string
+	Total  uint64 // messages dropped by the stream so far.
+	Time   time.Time
+}
+
+func (e DroppedEvent) EventTime() time.Time { return e.Time }
+
+// BundleResultEvent is published for every result received on the searcher bundle results stream.
+type BundleResultEvent struct {
+	Result *jito_pb.BundleResult
+	Time   time.Time
+}
+
+func (e BundleResultEvent) EventTime() time.Time { return e.Time }
+
+// HeartbeatEvent is published after every ShredStream heartbeat, Err is set when it failed.
+type HeartbeatEvent struct {
+	Socket *jito_pb.Socket // address shreds are sent to.
+	TTL    time.Duration   // delay before which the next heartbeat is due, zero on failure.
+	Err    error
+	Time   time.Time
+}
+
+func (e HeartbeatEvent) EventTime() time.Time { return e.Time }
+
+// ErrorEvent carries errors happening in background goroutines.
+type ErrorEvent struct {
+	Err  error
+	Time time.Time
+}
+
+func (e ErrorEvent) EventTime() time.Time { return e.Time }
+
+type subscriber struct {
+	deliver func(Event) bool // returns false if the event was dropped.
+	close   func()
+	dropped *atomic.Uint64
+}
+
+// EventBus fans events out to any number of subscribers. Publishing never blocks,
+// events are dropped for subscribers whose buffer is full.
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[*subscriber]struct{})}
+}
+
+// Publish delivers event to every subscriber interested in its type, it is a no-op on a nil or closed bus.
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.deliver(event) {
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscription is returned by Subscribe, Cancel stops the delivery and closes the channel.
+type Subscription[T Event] struct {
+	C <-chan T
+
+	bus     *EventBus
+	sub     *subscriber
+	dropped atomic.Uint64
+}
+
+// Dropped returns the amount of events dropped because C was full.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Cancel unsubscribes, it can be called several times.
+func (s *Subscription[T]) Cancel() {
+	if s.bus == nil {
+		return
+	}
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subs[s.sub]; ok {
+		delete(s.bus.subs, s.sub)
+		s.sub.close()
+	}
+}
+
+// Subscribe returns a subscription receiving the events of type T published on bus, e.g. Subscribe[BundleResultEvent],
+// or every event with Subscribe[Event]. buffer is the capacity of the channel, the channel is closed once bus is closed.
+// Like Publish, it accepts a nil bus: the channel of the subscription is closed already.
+func Subscribe[T Event](bus *EventBus, buffer int) *Subscription[T] {
+	ch := make(chan T, buffer)
+	s := &Subscription[T]{C: ch, bus: bus}
+	s.sub = &subscriber{
+		deliver: func(event Event) bool {
+			e, ok := event.(T)
+			if !ok {
+				return true
+			}
+
+			select {
+			case ch <- e:
+				return true
+			default:
+				return false
+			}
+		},
+		close:   func() { close(ch) },
+		dropped: &s.dropped,
+	}
+
+	if bus == nil {
+		close(ch)
+		return s
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.closed {
+		close(ch)
+		return s
+	}
+	bus.subs[s.sub] = struct{}{}
+
+	return s
+}
+
+// Close closes the channel of every subscriber, later events are discarded.
+func (b *EventBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	for sub := range b.subs {
+		sub.close()
+	}
+	b.subs = nil
+
+	return nil
+}
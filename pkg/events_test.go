@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go/pb"
+	"testing"
+	"time"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+
+	all := Subscribe[Event](bus, 8)
+	results := Subscribe[BundleResultEvent](bus, 1)
+	cancelled := Subscribe[ErrorEvent](bus, 1)
+	cancelled.Cancel()
+	cancelled.Cancel()
+
+	bus.Publish(BundleResultEvent{Result: &jito_pb.BundleResult{BundleId: "first"}})
+	bus.Publish(BundleResultEvent{Result: &jito_pb.BundleResult{BundleId: "second"}})
+	bus.Publish(ErrorEvent{Err: errors.New("boom")})
+
+	// the full subscriber drops without blocking the others
+	assert.Equal(t, "first", (<-results.C).Result.GetBundleId())
+	assert.Equal(t, uint64(1), results.Dropped())
+	assert.Len(t, all.C, 3)
+
+	_, ok := <-cancelled.C
+	assert.False(t, ok)
+
+	assert.NoError(t, bus.Close())
+	bus.Publish(ErrorEvent{})
+	for range all.C {
+	}
+
+	_, ok = <-Subscribe[Event](bus, 1).C
+	assert.False(t, ok)
+
+	nilSub := Subscribe[Event](nil, 1)
+	_, ok = <-nilSub.C
+	assert.False(t, ok)
+	nilSub.Cancel()
+}
+
+func TestResilientStreamEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bus := NewEventBus()
+	events := Subscribe[Event](bus, 16)
+
+	_, err := NewResilientStream(ctx, func(ctx context.Context) (Receiver[int], error) {
+		return &fakeReceiver{ctx: ctx, msgs: []int{1, 2}}, nil
+	}, WithBuffer(1, DropNewest), WithEventBus(bus, "numbers"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	connected := (<-events.C).(StreamEvent)
+	assert.Equal(t, "numbers", connected.Stream)
+	assert.Equal(t, StreamConnected, connected.Kind)
+
+	dropped := (<-events.C).(DroppedEvent)
+	assert.Equal(t, "numbers", dropped.Stream)
+	assert.Equal(t, uint64(1), dropped.Total)
+}
@@ -8,19 +8,21 @@ import (
 )
 
 // CreateAndObserveGRPCConn creates a new gRPC connection and observes its conn status.
+//
+// Deprecated: the connection recreated by the observer is never handed back to the caller, use NewConnSupervisor instead.
 func CreateAndObserveGRPCConn(ctx context.Context, chErr chan error, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	go func() {
 		var retries int
 		for {
 			select {
 			case <-ctx.Done():
 				if err = conn.Close(); err != nil {
-					chErr <- err
+					DispatchError(chErr, err)
 				}
 				return
 			default:
@@ -30,7 +32,7 @@ func CreateAndObserveGRPCConn(ctx context.Context, chErr chan error, target stri
 					time.Sleep(1 * time.Second)
 					continue
 				}
-				
+
 				if state == connectivity.TransientFailure || state == connectivity.Connecting || state == connectivity.Idle {
 					if retries < 5 {
 						time.Sleep(time.Duration(retries) * time.Second)
@@ -40,24 +42,32 @@ func CreateAndObserveGRPCConn(ctx context.Context, chErr chan error, target stri
 						conn.Close()
 						conn, err = grpc.NewClient(target, opts...)
 						if err != nil {
-							chErr <- err
+							DispatchError(chErr, err)
 						}
 						retries = 0
 					}
 				} else if state == connectivity.Shutdown {
 					conn, err = grpc.NewClient(target, opts...)
 					if err != nil {
-						chErr <- err
+						DispatchError(chErr, err)
 					}
 					retries = 0
 				}
-				
+
 				if !conn.WaitForStateChange(ctx, state) {
 					continue
 				}
 			}
 		}
 	}()
-	
+
 	return conn, nil
 }
+
+// DispatchError sends err to chErr without blocking, err is dropped when chErr is full, nil or not received from.
+func DispatchError(chErr chan<- error, err error) {
+	select {
+	case chErr <- err:
+	default:
+	}
+}
@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNoHedgedCalls = errors.New("no call to hedge")
+
+type hedgeResult[T any] struct {
+	value T
+	err   error
+}
+
+// Hedge runs calls[0], then starts the next call each time delay elapses without a successful answer, or as soon as
+// the previous call failed. The first successful result is returned and the contexts of the other calls are cancelled.
+// When every call fails their errors are joined.
+func Hedge[T any](ctx context.Context, delay time.Duration, calls ...func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if len(calls) == 0 {
+		return zero, ErrNoHedgedCalls
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered so the losers never block once we returned
+	results := make(chan hedgeResult[T], len(calls))
+	start := func(call func(ctx context.Context) (T, error)) {
+		go func() {
+			value, err := call(ctx)
+			results <- hedgeResult[T]{value, err}
+		}()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	start(calls[0])
+	started, pending := 1, 1
+	var errs []error
+	for pending > 0 {
+		select {
+		case <-ctx.Done():
+			return zero, errors.Join(append(errs, ctx.Err())...)
+		case <-timer.C:
+			if started < len(calls) {
+				start(calls[started])
+				started++
+				pending++
+				timer.Reset(delay)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				return result.value, nil
+			}
+			errs = append(errs, result.err)
+
+			if started < len(calls) {
+				start(calls[started])
+				started++
+				pending++
+				timer.Reset(delay)
+			}
+		}
+	}
+
+	return zero, errors.Join(errs...)
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := context.Background()
+
+	var cancelled atomic.Bool
+	slow := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		cancelled.Store(true)
+		return "", ctx.Err()
+	}
+	fast := func(context.Context) (string, error) {
+		return "fast", nil
+	}
+	failing := func(context.Context) (string, error) {
+		return "", errors.New("unavailable")
+	}
+
+	_, err := Hedge[string](ctx, time.Millisecond)
+	assert.ErrorIs(t, err, ErrNoHedgedCalls)
+
+	// the secondary answers once the primary stalls for longer than delay
+	value, err := Hedge(ctx, 10*time.Millisecond, slow, fast)
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", value)
+	assert.Eventually(t, cancelled.Load, time.Second, time.Millisecond)
+
+	// a failed primary does not wait for the delay
+	begin := time.Now()
+	value, err = Hedge(ctx, time.Hour, failing, fast)
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", value)
+	assert.Less(t, time.Since(begin), time.Second)
+
+	_, err = Hedge(ctx, time.Millisecond, failing, failing)
+	assert.ErrorContains(t, err, "unavailable")
+}
@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"sync"
+	"time"
+)
+
+// DefaultInterestTTL is how long an account or program of interest is kept without being sent again by the block engine.
+const DefaultInterestTTL = 70 * time.Second
+
+// InterestSet is a concurrency-safe set of public keys whose entries expire unless they are added again.
+type InterestSet struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[solana.PublicKey]time.Time // expiry of every key.
+}
+
+// NewInterestSet keeps every key for ttl after it was last added, DefaultInterestTTL when not positive.
+func NewInterestSet(ttl time.Duration) *InterestSet {
+	if ttl <= 0 {
+		ttl = DefaultInterestTTL
+	}
+	return &InterestSet{ttl: ttl, entries: make(map[solana.PublicKey]time.Time)}
+}
+
+// Add inserts keys or extends their lifetime, expired keys are removed along the way.
+func (s *InterestSet) Add(keys ...solana.PublicKey) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, expiry := range s.entries {
+		if now.After(expiry) {
+			delete(s.entries, key)
+		}
+	}
+
+	for _, key := range keys {
+		s.entries[key] = now.Add(s.ttl)
+	}
+}
+
+// AddStrings is Add for base58 keys, invalid keys are skipped and returned.
+func (s *InterestSet) AddStrings(keys []string) []string {
+	valid := make([]solana.PublicKey, 0, len(keys))
+	var invalid []string
+	for _, key := range keys {
+		pubkey, err := solana.PublicKeyFromBase58(key)
+		if err != nil {
+			invalid = append(invalid, key)
+			continue
+		}
+		valid = append(valid, pubkey)
+	}
+
+	s.Add(valid...)
+	return invalid
+}
+
+// Contains reports whether key was added less than ttl ago.
+func (s *InterestSet) Contains(key solana.PublicKey) bool {
+	s.mu.RLock()
+	expiry, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	return ok && time.Now().Before(expiry)
+}
+
+// Keys returns the keys which did not expire.
+func (s *InterestSet) Keys() []solana.PublicKey {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]solana.PublicKey, 0, len(s.entries))
+	for key, expiry := range s.entries {
+		if now.Before(expiry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// InterestRegistry holds the accounts and programs of interest a relayer forwards packets for.
+type InterestRegistry struct {
+	Accounts *InterestSet
+	Programs *InterestSet
+}
+
+func NewInterestRegistry(ttl time.Duration) *InterestRegistry {
+	return &InterestRegistry{Accounts: NewInterestSet(ttl), Programs: NewInterestSet(ttl)}
+}
+
+// Matches reports whether tx references an account of interest or invokes a program of interest.
+// Only the static account keys are inspected, accounts loaded from address lookup tables are not seen.
+func (r *InterestRegistry) Matches(tx *solana.Transaction) bool {
+	keys := tx.Message.AccountKeys
+	for _, key := range keys {
+		if r.Accounts.Contains(key) {
+			return true
+		}
+	}
+
+	for _, inst := range tx.Message.Instructions {
+		if int(inst.ProgramIDIndex) < len(keys) && r.Programs.Contains(keys[inst.ProgramIDIndex]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filter returns the transactions of txns matching the registry, in order.
+func (r *InterestRegistry) Filter(txns []*solana.Transaction) []*solana.Transaction {
+	var matching []*solana.Transaction
+	for _, tx := range txns {
+		if r.Matches(tx) {
+			matching = append(matching, tx)
+		}
+	}
+	return matching
+}
@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInterestSet(t *testing.T) {
+	set := NewInterestSet(50 * time.Millisecond)
+	key := solana.NewWallet().PublicKey()
+
+	assert.Equal(t, []string{"invalid"}, set.AddStrings([]string{key.String(), "invalid"}))
+	assert.True(t, set.Contains(key))
+	assert.Equal(t, []solana.PublicKey{key}, set.Keys())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, set.Contains(key))
+	assert.Empty(t, set.Keys())
+}
+
+func TestInterestRegistry(t *testing.T) {
+	registry := NewInterestRegistry(time.Minute)
+	tx := newTestTransaction(t)
+	assert.False(t, registry.Matches(tx))
+
+	registry.Programs.Add(solana.SystemProgramID)
+	assert.True(t, registry.Matches(tx))
+
+	registry = NewInterestRegistry(time.Minute)
+	// the recipient of the transfer
+	registry.Accounts.Add(tx.Message.AccountKeys[1])
+	assert.Len(t, registry.Filter([]*solana.Transaction{tx, newTestTransaction(t)}), 1)
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Lifecycle owns the root context of a client, the goroutines started under it and the resources to release on Close.
+type Lifecycle struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	closing bool // no goroutine can be added once set.
+	closed  bool
+	closers []func() error
+	errs    chan error
+	events  *EventBus
+
+	once     sync.Once
+	closeErr error
+}
+
+// NewLifecycle derives the root context from parent, the lifecycle ends when parent is done or Close is called.
+// errBuffer sizes the errors channel returned by Errors.
+func NewLifecycle(parent context.Context, errBuffer int) *Lifecycle {
+	ctx, cancel := context.WithCancel(parent)
+	return &Lifecycle{
+		ctx:    ctx,
+		cancel: cancel,
+		errs:   make(chan error, errBuffer),
+		events: NewEventBus(),
+	}
+}
+
+// Context returns the root context, cancelled once Close is called.
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Errors returns the channel errors are dispatched to, it is closed by Close once every goroutine has exited.
+func (l *Lifecycle) Errors() chan error {
+	return l.errs
+}
+
+// Events returns the event bus of the client, it is closed by Close after the errors channel.
+func (l *Lifecycle) Events() *EventBus {
+	return l.events
+}
+
+// Go runs fn in a goroutine Close waits for, fn must return once ctx is done. fn is not run once Close was called.
+func (l *Lifecycle) Go(fn func(ctx context.Context)) {
+	if !l.add() {
+		return
+	}
+	go func() {
+		defer l.wg.Done()
+		fn(l.ctx)
+	}()
+}
+
+func (l *Lifecycle) add() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closing {
+		return false
+	}
+
+	l.wg.Add(1)
+	return true
+}
+
+// Bind returns a copy of ctx which is also cancelled when the lifecycle ends.
+func (l *Lifecycle) Bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(l.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// OnClose registers fn to be called by Close after every goroutine has exited, in the reverse order of registration.
+func (l *Lifecycle) OnClose(fn func() error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closers = append(l.closers, fn)
+}
+
+// Dispatch publishes err as an ErrorEvent and sends it to the errors channel without blocking,
+// it is dropped if nobody listens or once closed.
+func (l *Lifecycle) Dispatch(err error) {
+	l.events.Publish(ErrorEvent{Err: err, Time: time.Now()})
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return
+	}
+
+	DispatchError(l.errs, err)
+}
+
+// Close cancels the root context, waits for the goroutines, runs the OnClose callbacks and closes the errors channel.
+// It is safe to call several times, every call returns the errors joined by the first one.
+func (l *Lifecycle) Close() error {
+	l.once.Do(func() {
+		l.mu.Lock()
+		l.closing = true
+		closers := l.closers
+		l.mu.Unlock()
+
+		l.cancel()
+		l.wg.Wait()
+
+		var errs []error
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		l.mu.Lock()
+		l.closed = true
+		close(l.errs)
+		l.mu.Unlock()
+		l.events.Close()
+
+		l.closeErr = errors.Join(errs...)
+	})
+
+	return l.closeErr
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	lifecycle := NewLifecycle(context.Background(), 1)
+
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	var order []string
+	lifecycle.OnClose(func() error {
+		order = append(order, "first")
+		return errFirst
+	})
+	lifecycle.OnClose(func() error {
+		order = append(order, "second")
+		return errSecond
+	})
+
+	exited := make(chan struct{})
+	lifecycle.Go(func(ctx context.Context) {
+		defer close(exited)
+		<-ctx.Done()
+		// still allowed while Close waits for the goroutines
+		lifecycle.Dispatch(ctx.Err())
+	})
+
+	bound, cancel := lifecycle.Bind(context.Background())
+	defer cancel()
+
+	err := lifecycle.Close()
+	assert.ErrorIs(t, err, errFirst)
+	assert.ErrorIs(t, err, errSecond)
+	assert.Equal(t, []string{"second", "first"}, order)
+
+	select {
+	case <-exited:
+	default:
+		t.Fatal("Close returned before the goroutine exited")
+	}
+
+	select {
+	case <-bound.Done():
+	case <-time.After(time.Second):
+		t.Fatal("bound context was not cancelled")
+	}
+
+	assert.ErrorIs(t, <-lifecycle.Errors(), context.Canceled)
+	_, ok := <-lifecycle.Errors()
+	assert.False(t, ok)
+
+	// closed lifecycles ignore new work instead of panicking
+	lifecycle.Dispatch(errFirst)
+	lifecycle.Go(func(context.Context) { t.Error("goroutine started after Close") })
+	assert.Equal(t, err, lifecycle.Close())
+	assert.Len(t, order, 2)
+}
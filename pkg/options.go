@@ -0,0 +1,371 @@
+package pkg
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/weeaa/jito-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultKeepAlive is applied to proxied connections when no keepalive is configured, proxies tend to drop idle tunnels.
+var DefaultKeepAlive = keepalive.ClientParameters{
+	Time:                15 * time.Second,
+	Timeout:             5 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// MetricsHooks are called by every client built from ClientOptions, nil hooks are skipped.
+type MetricsHooks struct {
+	OnCall       func(method string, duration time.Duration, err error) // after every unary call.
+	OnStreamOpen func(method string, err error)
+	OnReconnect  func(target string)
+}
+
+// ClientOptions holds the settings shared by the constructors of every client package.
+// It is built from ClientOption values, the zero value of each field keeps the default behaviour.
+type ClientOptions struct {
+	Endpoint     string
+	RegionRanker *RegionRanker // used to pick the fastest region when Endpoint is empty.
+
+	PrivateKey solana.PrivateKey
+	NoAuth     bool
+
+	TLSConfig *tls.Config
+	Insecure  bool
+	KeepAlive *keepalive.ClientParameters
+
+	ProxyURL  string
+	ProxyPool *ProxyPool
+
+	Retry              *RetryConfig // nil disables the retry interceptor.
+	Cache              *Cache       // serves slow-changing reads, nil by default.
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+	DialOptions        []grpc.DialOption
+
+	Logger  *slog.Logger
+	Metrics MetricsHooks
+
+	DialTimeout    time.Duration // minimum time given to establish a connection.
+	RequestTimeout time.Duration // applied to unary calls and HTTP requests whose context has no deadline.
+
+	StreamBufferSize     int
+	StreamOverflowPolicy OverflowPolicy
+
+	RpcClient, JitoRpcClient *rpc.Client
+	HTTPClient               *http.Client
+}
+
+type ClientOption func(*ClientOptions)
+
+// NewClientOptions applies opts over the defaults.
+func NewClientOptions(opts ...ClientOption) *ClientOptions {
+	o := &ClientOptions{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithEndpoint sets the gRPC address the client connects to, e.g. jito_go.NewYork.BlockEngineURL.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(o *ClientOptions) { o.Endpoint = endpoint }
+}
+
+// WithFastestRegion connects to the fastest region of ranker when no endpoint is set.
+func WithFastestRegion(ranker *RegionRanker) ClientOption {
+	return func(o *ClientOptions) { o.RegionRanker = ranker }
+}
+
+// WithPrivateKey sets the identity used to authenticate against Jito.
+func WithPrivateKey(privateKey solana.PrivateKey) ClientOption {
+	return func(o *ClientOptions) { o.PrivateKey = privateKey }
+}
+
+// WithNoAuth skips authentication, only supported by the searcher client.
+func WithNoAuth() ClientOption {
+	return func(o *ClientOptions) { o.NoAuth = true }
+}
+
+// WithTLSConfig overrides the default TLS configuration.
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(o *ClientOptions) { o.TLSConfig = tlsConfig }
+}
+
+// WithInsecure disables transport security, meant for local endpoints.
+func WithInsecure() ClientOption {
+	return func(o *ClientOptions) { o.Insecure = true }
+}
+
+func WithKeepAlive(params keepalive.ClientParameters) ClientOption {
+	return func(o *ClientOptions) { o.KeepAlive = &params }
+}
+
+// WithProxy routes the connection through a proxy in any format accepted by ParseProxyURL, empty means no proxy.
+func WithProxy(proxyURL string) ClientOption {
+	return func(o *ClientOptions) { o.ProxyURL = proxyURL }
+}
+
+// WithProxyPool routes the connection through pool, it takes precedence over WithProxy.
+func WithProxyPool(pool *ProxyPool) ClientOption {
+	return func(o *ClientOptions) { o.ProxyPool = pool }
+}
+
+// WithRetryConfig retries unary calls with config, e.g. DefaultRetryConfig, see RetryUnaryInterceptor.
+func WithRetryConfig(config RetryConfig) ClientOption {
+	return func(o *ClientOptions) { o.Retry = &config }
+}
+
+// WithoutRetry disables the retry interceptor enabled by an earlier WithRetryConfig.
+func WithoutRetry() ClientOption {
+	return func(o *ClientOptions) { o.Retry = nil }
+}
+
+// WithCache serves the methods cached by cache without hitting the network, cache can be shared by several clients.
+func WithCache(cache *Cache) ClientOption {
+	return func(o *ClientOptions) { o.Cache = cache }
+}
+
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) ClientOption {
+	return func(o *ClientOptions) { o.UnaryInterceptors = append(o.UnaryInterceptors, interceptors...) }
+}
+
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) ClientOption {
+	return func(o *ClientOptions) { o.StreamInterceptors = append(o.StreamInterceptors, interceptors...) }
+}
+
+// WithDialOptions appends raw gRPC dial options, applied after every other option.
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(o *ClientOptions) { o.DialOptions = append(o.DialOptions, opts...) }
+}
+
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(o *ClientOptions) {
+		if logger != nil {
+			o.Logger = logger
+		}
+	}
+}
+
+func WithMetrics(hooks MetricsHooks) ClientOption {
+	return func(o *ClientOptions) { o.Metrics = hooks }
+}
+
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.DialTimeout = timeout }
+}
+
+func WithRequestTimeout(timeout time.Duration) ClientOption {
+	return func(o *ClientOptions) { o.RequestTimeout = timeout }
+}
+
+// WithStreamBuffer sets the default buffer of every stream opened by the client, see WithBuffer.
+func WithStreamBuffer(size int, policy OverflowPolicy) ClientOption {
+	return func(o *ClientOptions) {
+		o.StreamBufferSize = size
+		o.StreamOverflowPolicy = policy
+	}
+}
+
+// WithRPCClients sets the Solana RPC client and the Jito RPC client used by the searcher client.
+func WithRPCClients(rpcClient, jitoRpcClient *rpc.Client) ClientOption {
+	return func(o *ClientOptions) {
+		o.RpcClient = rpcClient
+		o.JitoRpcClient = jitoRpcClient
+	}
+}
+
+// WithHTTPClient sets the HTTP client used by HTTP based clients, the proxy options are applied on top of it.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(o *ClientOptions) { o.HTTPClient = client }
+}
+
+// ResolveEndpoint returns Endpoint, or the address picked by field from the fastest region when a RegionRanker is set.
+func (o *ClientOptions) ResolveEndpoint(ctx context.Context, field func(jito_go.JitoEndpointInfo) string) (string, error) {
+	if o.Endpoint != "" {
+		return o.Endpoint, nil
+	}
+
+	if o.RegionRanker == nil {
+		return "", errors.New("missing endpoint, use WithEndpoint or WithFastestRegion")
+	}
+
+	if o.RegionRanker.Ranking() == nil {
+		o.RegionRanker.Rank(ctx)
+	}
+
+	endpoint, err := o.RegionRanker.Fastest()
+	if err != nil {
+		return "", err
+	}
+
+	return field(endpoint), nil
+}
+
+// BaseDialOptions returns the gRPC dial options built from the options, proxy excluded.
+func (o *ClientOptions) BaseDialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+
+	switch {
+	case o.Insecure:
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	case o.TLSConfig != nil:
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(o.TLSConfig)))
+	default:
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	}
+
+	if o.KeepAlive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(*o.KeepAlive))
+	}
+
+	if o.DialTimeout > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoff.DefaultConfig, MinConnectTimeout: o.DialTimeout}))
+	}
+
+	var unary []grpc.UnaryClientInterceptor
+	// cache hits skip the timeout, metrics and retries
+	if o.Cache != nil {
+		unary = append(unary, o.Cache.UnaryInterceptor())
+	}
+	unary = append(unary, o.unaryInterceptor)
+	if o.Retry != nil {
+		unary = append(unary, RetryUnaryInterceptor(*o.Retry))
+	}
+	unary = append(unary, o.UnaryInterceptors...)
+	stream := append([]grpc.StreamClientInterceptor{o.streamInterceptor}, o.StreamInterceptors...)
+	opts = append(opts, grpc.WithChainUnaryInterceptor(unary...), grpc.WithChainStreamInterceptor(stream...))
+
+	return append(opts, o.DialOptions...)
+}
+
+func (o *ClientOptions) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, ok := ctx.Deadline(); !ok && o.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.RequestTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if o.Metrics.OnCall != nil {
+		o.Metrics.OnCall(method, time.Since(start), err)
+	}
+
+	return err
+}
+
+func (o *ClientOptions) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if o.Metrics.OnStreamOpen != nil {
+		o.Metrics.OnStreamOpen(method, err)
+	}
+
+	return stream, err
+}
+
+// Dial creates the ConnSupervisor of a client connected to target with every option applied.
+// The supervisor is owned by lifecycle, reports to its errors channel and event bus and is closed with it.
+func (o *ClientOptions) Dial(lifecycle *Lifecycle, target string) (*ConnSupervisor, error) {
+	opts := o.BaseDialOptions()
+
+	// the pool rotates by recreating the connection, which only exists once the supervisor is created
+	var supervisor atomic.Pointer[ConnSupervisor]
+	switch {
+	case o.ProxyPool != nil:
+		opts = append(opts, o.ProxyPool.DialOptions(ReconnectFunc(lifecycle, supervisor.Load))...)
+	case o.ProxyURL != "":
+		proxyOpt, err := ProxyDialOption(o.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy dialer: %w", err)
+		}
+		opts = append(opts, proxyOpt)
+	}
+
+	if (o.ProxyPool != nil || o.ProxyURL != "") && o.KeepAlive == nil {
+		opts = append(opts, grpc.WithKeepaliveParams(DefaultKeepAlive))
+	}
+
+	s, err := NewConnSupervisor(lifecycle.Context(), lifecycle.Errors(), target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	supervisor.Store(s)
+	s.SetEventBus(lifecycle.Events())
+	lifecycle.OnClose(s.Close)
+
+	s.OnReconnect(func(*grpc.ClientConn) {
+		o.Logger.Info("grpc connection recreated", "target", target)
+		if o.Metrics.OnReconnect != nil {
+			o.Metrics.OnReconnect(target)
+		}
+	})
+
+	return s, nil
+}
+
+// ReconnectFunc returns the rotate function given to ProxyPool.DialOptions by the clients: it recreates the connection
+// of the supervisor returned by supervisor on a goroutine of lifecycle, so that it never outlives the client.
+// Errors are dispatched to lifecycle.
+func ReconnectFunc(lifecycle *Lifecycle, supervisor func() *ConnSupervisor) func() error {
+	return func() error {
+		lifecycle.Go(func(context.Context) {
+			s := supervisor()
+			if s == nil {
+				return
+			}
+			if err := s.Reconnect(); err != nil && !errors.Is(err, ErrSupervisorClosed) {
+				lifecycle.Dispatch(err)
+			}
+		})
+		return nil
+	}
+}
+
+// StreamOptions returns the stream options every subscription of the client starts from.
+func (o *ClientOptions) StreamOptions() []StreamOption {
+	if o.StreamBufferSize <= 0 {
+		return nil
+	}
+
+	return []StreamOption{WithBuffer(o.StreamBufferSize, o.StreamOverflowPolicy)}
+}
+
+// NewHTTPClient returns HTTPClient, or a new client, with the proxy options and RequestTimeout applied.
+func (o *ClientOptions) NewHTTPClient() (*http.Client, error) {
+	client := &http.Client{}
+	if o.HTTPClient != nil {
+		c := *o.HTTPClient
+		client = &c
+	}
+
+	switch {
+	case o.ProxyPool != nil:
+		transport, _ := client.Transport.(*http.Transport)
+		client.Transport = o.ProxyPool.Transport(transport)
+	case o.ProxyURL != "":
+		if err := SetHTTPProxy(client, o.ProxyURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if client.Timeout == 0 {
+		client.Timeout = o.RequestTimeout
+	}
+
+	return client, nil
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var calls atomic.Int32
+	o := NewClientOptions(
+		WithInsecure(),
+		WithRequestTimeout(time.Second),
+		WithStreamBuffer(8, DropOldest),
+		WithMetrics(MetricsHooks{OnCall: func(method string, duration time.Duration, err error) {
+			assert.Equal(t, "/grpc.health.v1.Health/Check", method)
+			assert.NoError(t, err)
+			calls.Add(1)
+		}}),
+	)
+
+	// calls are only retried once asked for
+	assert.Nil(t, o.Retry)
+	assert.Equal(t, &DefaultRetryConfig, NewClientOptions(WithRetryConfig(DefaultRetryConfig)).Retry)
+	assert.Nil(t, NewClientOptions(WithRetryConfig(DefaultRetryConfig), WithoutRetry()).Retry)
+
+	_, err := o.ResolveEndpoint(ctx, nil)
+	assert.Error(t, err)
+	assert.Len(t, o.StreamOptions(), 1)
+
+	lifecycle := NewLifecycle(ctx, 0)
+	defer lifecycle.Close()
+
+	supervisor, err := o.Dial(lifecycle, newHealthServer(t))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	_, err = grpc_health_v1.NewHealthClient(supervisor).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
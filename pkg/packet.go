@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/weeaa/jito-go/pb"
+	"time"
+)
+
+// PacketMeta is the metadata the block engine or the relayer attaches to every packet.
+type PacketMeta struct {
+	Size        uint64
+	Addr        string // address of the sender.
+	Port        uint32
+	SenderStake uint64
+
+	Discard        bool
+	Forwarded      bool
+	Repair         bool
+	SimpleVote     bool
+	Tracer         bool
+	FromStakedNode bool
+}
+
+// NewPacketMeta flattens meta, a nil meta gives the zero PacketMeta.
+func NewPacketMeta(meta *jito_pb.Meta) PacketMeta {
+	flags := meta.GetFlags()
+	return PacketMeta{
+		Size:           meta.GetSize(),
+		Addr:           meta.GetAddr(),
+		Port:           meta.GetPort(),
+		SenderStake:    meta.GetSenderStake(),
+		Discard:        flags.GetDiscard(),
+		Forwarded:      flags.GetForwarded(),
+		Repair:         flags.GetRepair(),
+		SimpleVote:     flags.GetSimpleVoteTx(),
+		Tracer:         flags.GetTracerPacket(),
+		FromStakedNode: flags.GetFromStakedNode(),
+	}
+}
+
+// Packet is a transaction decoded from a jito_pb.Packet, along with its metadata.
+type Packet struct {
+	Transaction *solana.Transaction
+	Meta        PacketMeta
+	Timestamp   time.Time // timestamp of the batch header, zero when the batch had none.
+}
+
+// PacketFilter tells whether a packet is kept, it is evaluated before the packet is decoded.
+type PacketFilter func(meta PacketMeta) bool
+
+// SkipVotes drops simple vote transactions.
+func SkipVotes() PacketFilter {
+	return func(meta PacketMeta) bool { return !meta.SimpleVote }
+}
+
+// SkipDiscarded drops the packets flagged as discarded.
+func SkipDiscarded() PacketFilter {
+	return func(meta PacketMeta) bool { return !meta.Discard }
+}
+
+// MinSenderStake drops the packets sent by nodes staking less than stake lamports.
+func MinSenderStake(stake uint64) PacketFilter {
+	return func(meta PacketMeta) bool { return meta.SenderStake >= stake }
+}
+
+// PacketDecodeError is returned for every packet which could not be decoded, the other packets of its batch are kept.
+type PacketDecodeError struct {
+	Index int // position of the packet in its batch.
+	Meta  PacketMeta
+	Err   error
+}
+
+func (e *PacketDecodeError) Error() string {
+	return fmt.Sprintf("failed to decode packet %d from %s:%d: %v", e.Index, e.Meta.Addr, e.Meta.Port, e.Err)
+}
+
+func (e *PacketDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodePackets decodes the packets kept by filters, header gives their timestamp.
+// Packets failing to decode are reported as *PacketDecodeError.
+func DecodePackets(header *jito_pb.Header, packets []*jito_pb.Packet, filters ...PacketFilter) ([]Packet, []error) {
+	var timestamp time.Time
+	if ts := header.GetTs(); ts != nil {
+		timestamp = ts.AsTime()
+	}
+
+	decoded := make([]Packet, 0, len(packets))
+	var errs []error
+
+next:
+	for i, packet := range packets {
+		meta := NewPacketMeta(packet.GetMeta())
+		for _, filter := range filters {
+			if !filter(meta) {
+				continue next
+			}
+		}
+
+		tx, err := ConvertProtobufPacketToTransaction(packet)
+		if err != nil {
+			errs = append(errs, &PacketDecodeError{Index: i, Meta: meta, Err: err})
+			continue
+		}
+
+		decoded = append(decoded, Packet{Transaction: tx, Meta: meta, Timestamp: timestamp})
+	}
+
+	return decoded, errs
+}
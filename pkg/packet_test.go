@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func newTestTransaction(t *testing.T) *solana.Transaction {
+	payer := solana.NewWallet()
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+	}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &payer.PrivateKey }); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestDecodePackets(t *testing.T) {
+	tx := newTestTransaction(t)
+	packet, err := ConvertTransactionToProtobufPacket(tx)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	withMeta := func(meta *jito_pb.Meta) *jito_pb.Packet {
+		return &jito_pb.Packet{Data: packet.Data, Meta: meta}
+	}
+
+	ts := time.Unix(1700000000, 0).UTC()
+	packets := []*jito_pb.Packet{
+		withMeta(&jito_pb.Meta{Addr: "1.2.3.4", Port: 8001, SenderStake: 100, Flags: &jito_pb.PacketFlags{Forwarded: true, FromStakedNode: true}}),
+		withMeta(&jito_pb.Meta{SenderStake: 100, Flags: &jito_pb.PacketFlags{SimpleVoteTx: true}}),
+		withMeta(&jito_pb.Meta{SenderStake: 100, Flags: &jito_pb.PacketFlags{Discard: true}}),
+		withMeta(&jito_pb.Meta{SenderStake: 1}),
+		{Data: []byte{1, 2, 3}, Meta: &jito_pb.Meta{SenderStake: 100}},
+	}
+
+	decoded, errs := DecodePackets(&jito_pb.Header{Ts: timestamppb.New(ts)}, packets, SkipVotes(), SkipDiscarded(), MinSenderStake(10))
+	if assert.Len(t, decoded, 1) {
+		assert.Equal(t, tx.Signatures[0], decoded[0].Transaction.Signatures[0])
+		assert.Equal(t, PacketMeta{Addr: "1.2.3.4", Port: 8001, SenderStake: 100, Forwarded: true, FromStakedNode: true}, decoded[0].Meta)
+		assert.Equal(t, ts, decoded[0].Timestamp)
+	}
+
+	if assert.Len(t, errs, 1) {
+		var decodeErr *PacketDecodeError
+		assert.ErrorAs(t, errs[0], &decodeErr)
+		assert.Equal(t, 4, decodeErr.Index)
+	}
+
+	decoded, errs = DecodePackets(nil, packets[:4])
+	assert.Len(t, decoded, 4)
+	assert.Empty(t, errs)
+	assert.True(t, decoded[0].Timestamp.IsZero())
+}
@@ -0,0 +1,445 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrNoHealthyProxy is returned when every proxy of a ProxyPool is unhealthy.
+var ErrNoHealthyProxy = errors.New("no healthy proxy available")
+
+type RotationPolicy int
+
+// A gRPC connection carries streams and concurrent calls, it is only re-dialed once its proxy fails or is rate limited:
+// RotatePerRequest and RotateEveryN pick the proxy of every HTTP request and of every new gRPC connection.
+const (
+	// RotatePerRequest switches to the next healthy proxy on every request.
+	RotatePerRequest RotationPolicy = iota
+	// RotateEveryN switches to the next healthy proxy every N requests.
+	RotateEveryN
+	// RotateOnError keeps the same proxy until a request going through it fails.
+	RotateOnError
+)
+
+type ProxyPoolConfig struct {
+	Policy RotationPolicy
+	N      int // Requests per proxy with RotateEveryN.
+
+	ProbeTarget   string        // host:port reached through each proxy when probing, defaults to the mainnet block engine.
+	ProbeInterval time.Duration // Interval between probes once Start is called, defaults to 1 minute.
+	ProbeTimeout  time.Duration // Defaults to 10 seconds.
+	Cooldown      time.Duration // How long a failing or rate limited proxy is left aside, defaults to 1 minute.
+}
+
+// ProxyStats holds the statistics of a single proxy of a ProxyPool.
+type ProxyStats struct {
+	Proxy        string // redacted proxy URL.
+	Healthy      bool
+	Requests     uint64
+	Failures     uint64
+	RateLimited  uint64
+	ProbeLatency time.Duration
+	LastError    error
+	LastChecked  time.Time
+}
+
+type pooledProxy struct {
+	url    *url.URL
+	dialer ContextDialer
+
+	healthy       bool
+	cooldownUntil time.Time
+	rateLimited   bool // the cooldown is not cut short by a successful probe.
+	stats         ProxyStats
+}
+
+// ProxyPool probes a set of proxies and rotates requests or connections across the healthy ones.
+// Failing and rate limited proxies are left aside for the Cooldown, a successful probe brings back failing ones earlier.
+type ProxyPool struct {
+	config  ProxyPoolConfig
+	mu      sync.Mutex
+	proxies []*pooledProxy
+	current int
+	uses    int
+}
+
+// NewProxyPool creates a pool from proxies in any format accepted by ParseProxyURL. Proxies are considered healthy until probed.
+func NewProxyPool(proxies []string, config ProxyPoolConfig) (*ProxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, errors.New("proxy pool requires at least one proxy")
+	}
+
+	if config.ProbeTarget == "" {
+		config.ProbeTarget = "mainnet.block-engine.jito.wtf:443"
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = time.Minute
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = 10 * time.Second
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = time.Minute
+	}
+	if config.N <= 0 {
+		config.N = 1
+	}
+
+	pool := &ProxyPool{config: config}
+	for _, proxyStr := range proxies {
+		proxyURL, err := ParseProxyURL(proxyStr)
+		if err != nil {
+			return nil, err
+		}
+
+		dialer, err := newURLProxyDialer(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+
+		pool.proxies = append(pool.proxies, &pooledProxy{
+			url:     proxyURL,
+			dialer:  dialer,
+			healthy: true,
+			stats:   ProxyStats{Proxy: proxyURL.Redacted(), Healthy: true},
+		})
+	}
+
+	return pool, nil
+}
+
+// Start probes every proxy now and then every ProbeInterval until ctx is done.
+func (p *ProxyPool) Start(ctx context.Context) {
+	p.Probe(ctx)
+
+	go func() {
+		ticker := time.NewTicker(p.config.ProbeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Probe(ctx)
+			}
+		}
+	}()
+}
+
+// Probe concurrently opens a connection to ProbeTarget through every proxy and updates their health.
+func (p *ProxyPool) Probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, proxy := range p.proxies {
+		wg.Add(1)
+		go func(proxy *pooledProxy) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, p.config.ProbeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			conn, err := proxy.dialer(probeCtx, p.config.ProbeTarget)
+			latency := time.Since(start)
+			if err == nil {
+				conn.Close()
+			}
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			proxy.stats.LastChecked = time.Now()
+			if err != nil {
+				p.fail(proxy, err)
+				return
+			}
+
+			proxy.stats.ProbeLatency = latency
+			proxy.stats.LastError = nil
+			// rate limited proxies wait for the end of their cooldown
+			if !proxy.rateLimited {
+				proxy.healthy = true
+				proxy.cooldownUntil = time.Time{}
+			}
+		}(proxy)
+	}
+	wg.Wait()
+}
+
+// Next returns the proxy the next request should go through according to the rotation policy.
+func (p *ProxyPool) Next() (*url.URL, error) {
+	proxy, err := p.next(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.url, nil
+}
+
+// next picks the current proxy, rotating first if the policy asks for it. count records a request on the proxy,
+// connections are not counted as their calls are counted individually.
+func (p *ProxyPool) next(count bool) (*pooledProxy, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refreshCooldowns()
+
+	if p.shouldRotate() {
+		p.advance()
+	}
+
+	if !p.proxies[p.current].healthy && !p.advance() {
+		return nil, ErrNoHealthyProxy
+	}
+
+	proxy := p.proxies[p.current]
+	if count {
+		p.uses++
+		proxy.stats.Requests++
+	}
+
+	return proxy, nil
+}
+
+func (p *ProxyPool) shouldRotate() bool {
+	switch p.config.Policy {
+	case RotatePerRequest:
+		return p.uses > 0
+	case RotateEveryN:
+		return p.uses >= p.config.N
+	default:
+		return false
+	}
+}
+
+// advance moves current to the next healthy proxy, it returns false if there is none.
+func (p *ProxyPool) advance() bool {
+	p.uses = 0
+	for i := 1; i <= len(p.proxies); i++ {
+		idx := (p.current + i) % len(p.proxies)
+		if p.proxies[idx].healthy {
+			p.current = idx
+			return true
+		}
+	}
+
+	return false
+}
+
+// refreshCooldowns brings back failing and rate limited proxies once their cooldown is over.
+func (p *ProxyPool) refreshCooldowns() {
+	now := time.Now()
+	for _, proxy := range p.proxies {
+		if !proxy.healthy && !proxy.cooldownUntil.IsZero() && now.After(proxy.cooldownUntil) {
+			proxy.healthy = true
+			proxy.cooldownUntil = time.Time{}
+			proxy.rateLimited = false
+		}
+	}
+}
+
+// fail leaves proxy aside for the cooldown after err, p.mu must be held.
+func (p *ProxyPool) fail(proxy *pooledProxy, err error) {
+	proxy.healthy = false
+	proxy.stats.LastError = err
+	if !proxy.rateLimited {
+		proxy.cooldownUntil = time.Now().Add(p.config.Cooldown)
+	}
+}
+
+// count records a request made on an already established connection through proxy.
+func (p *ProxyPool) count(proxy *pooledProxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy.stats.Requests++
+	if p.proxies[p.current] == proxy {
+		p.uses++
+	}
+}
+
+// report records the outcome of a request that went through proxy, it returns true if the request made a healthy
+// proxy unhealthy, so that connections going through it are re-dialed once only.
+func (p *ProxyPool) report(proxy *pooledProxy, err error, rateLimited bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil && !rateLimited {
+		return false
+	}
+
+	wasHealthy := proxy.healthy
+	proxy.stats.Failures++
+	if rateLimited {
+		proxy.stats.RateLimited++
+		proxy.healthy = false
+		proxy.rateLimited = true
+		proxy.cooldownUntil = time.Now().Add(p.config.Cooldown)
+	} else {
+		p.fail(proxy, err)
+	}
+
+	if p.proxies[p.current] == proxy {
+		p.advance()
+	}
+
+	return wasHealthy
+}
+
+// Stats returns a snapshot of every proxy statistics.
+func (p *ProxyPool) Stats() []ProxyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]ProxyStats, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		s := proxy.stats
+		s.Healthy = proxy.healthy
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+// proxiedAddr tags the remote address of connections opened by the pool, so that gRPC calls can be attributed to a proxy.
+type proxiedAddr struct {
+	net.Addr
+	proxy *pooledProxy
+}
+
+type proxiedConn struct {
+	net.Conn
+	addr proxiedAddr
+}
+
+func (c *proxiedConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// Dialer returns a dialer opening each connection through the proxy chosen by the rotation policy.
+func (p *ProxyPool) Dialer() ContextDialer {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		proxy, err := p.next(false)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := proxy.dialer(ctx, addr)
+		if err != nil {
+			p.report(proxy, err, false)
+			return nil, err
+		}
+
+		return &proxiedConn{Conn: conn, addr: proxiedAddr{Addr: conn.RemoteAddr(), proxy: proxy}}, nil
+	}
+}
+
+// DialOptions returns the gRPC dial options routing a connection through the pool. Each call outcome is reported to the
+// proxy that carried it and rotate is called once that proxy fails or is rate limited. rotate is called on the goroutine
+// of the call and must not block, see ReconnectFunc which recreates the connection on a goroutine of the client.
+// Rotating drops the calls and streams in flight, which is why the policy is not applied to established connections.
+func (p *ProxyPool) DialOptions(rotate func() error) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(p.Dialer()),
+		grpc.WithChainUnaryInterceptor(p.unaryInterceptor(rotate)),
+	}
+}
+
+func (p *ProxyPool) unaryInterceptor(rotate func() error) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var callPeer peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&callPeer))...)
+
+		addr, ok := callPeer.Addr.(proxiedAddr)
+		if !ok {
+			return err
+		}
+		p.count(addr.proxy)
+
+		// the deadline or cancellation of the caller says nothing about the proxy
+		if ctx.Err() != nil {
+			return err
+		}
+
+		// only transport level failures are attributed to the proxy, application errors say nothing about it
+		var proxyErr error
+		code := status.Code(err)
+		if code == codes.Unavailable || code == codes.DeadlineExceeded {
+			proxyErr = err
+		}
+
+		if p.report(addr.proxy, proxyErr, code == codes.ResourceExhausted) && rotate != nil {
+			rotate()
+		}
+
+		return err
+	}
+}
+
+type proxyContextKey struct{}
+
+// poolTransport is an http.RoundTripper picking a proxy from the pool for every request.
+type poolTransport struct {
+	pool *ProxyPool
+	base *http.Transport
+}
+
+// Transport returns an http.RoundTripper sending every request through the proxy chosen by the rotation policy.
+// HTTP 429 responses mark the proxy as rate limited. base may be nil.
+func (p *ProxyPool) Transport(base *http.Transport) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport := base.Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		proxy, ok := req.Context().Value(proxyContextKey{}).(*pooledProxy)
+		if !ok {
+			return nil, errors.New("request was not routed through the proxy pool")
+		}
+		return proxy.url, nil
+	}
+
+	return &poolTransport{pool: p, base: transport}
+}
+
+func (t *poolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxy, err := t.pool.next(true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(context.WithValue(req.Context(), proxyContextKey{}, proxy)))
+	if err != nil {
+		if req.Context().Err() == nil {
+			t.pool.report(proxy, err, false)
+		}
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		t.pool.report(proxy, nil, true)
+	case resp.StatusCode == http.StatusProxyAuthRequired || resp.StatusCode == http.StatusBadGateway:
+		t.pool.report(proxy, fmt.Errorf("proxy responded %s", resp.Status), false)
+	default:
+		t.pool.report(proxy, nil, false)
+	}
+
+	return resp, nil
+}
+
+// NewHTTPClient returns an HTTP client rotating its requests across the pool.
+func (p *ProxyPool) NewHTTPClient() *http.Client {
+	return &http.Client{Transport: p.Transport(nil)}
+}
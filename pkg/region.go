@@ -0,0 +1,217 @@
+package pkg
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/weeaa/jito-go"
+	"github.com/weeaa/jito-go/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRegionAvailable is returned when no region answered the last probe.
+var ErrNoRegionAvailable = errors.New("no block engine region available")
+
+// RegionLatency is the result of probing a single region.
+type RegionLatency struct {
+	Key      string // key of the region in jito_go.JitoEndpoints.
+	Endpoint jito_go.JitoEndpointInfo
+	RTT      time.Duration // median round-trip time of the probe calls.
+	Err      error
+	Time     time.Time
+}
+
+type RegionRankerConfig struct {
+	Endpoints   map[string]jito_go.JitoEndpointInfo // defaults to the mainnet entries of jito_go.JitoEndpoints.
+	Samples     int                                 // probe calls per region, defaults to 3.
+	Timeout     time.Duration                       // timeout of a whole region probe, defaults to 5 seconds.
+	Interval    time.Duration                       // interval between rankings once Start is called, defaults to 5 minutes.
+	DialOptions []grpc.DialOption                   // applied after the default TLS credentials, e.g. ProxyDialOption.
+
+	// Probe is the call timed against each block engine, defaults to an unauthenticated GetTipAccounts.
+	Probe func(ctx context.Context, conn *grpc.ClientConn) error
+}
+
+// RegionRanker measures the gRPC round-trip time to every block engine region and ranks them from fastest to slowest.
+type RegionRanker struct {
+	config RegionRankerConfig
+
+	mu      sync.RWMutex
+	ranking []RegionLatency
+	onRank  []func([]RegionLatency)
+}
+
+// NewRegionRanker creates a ranker, no region is probed until Rank or Start is called.
+func NewRegionRanker(config RegionRankerConfig) *RegionRanker {
+	if config.Endpoints == nil {
+		config.Endpoints = make(map[string]jito_go.JitoEndpointInfo)
+		for key, endpoint := range jito_go.JitoEndpoints {
+			if !strings.HasSuffix(key, "-TESTNET") {
+				config.Endpoints[key] = endpoint
+			}
+		}
+	}
+	if config.Samples <= 0 {
+		config.Samples = 3
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.Probe == nil {
+		config.Probe = func(ctx context.Context, conn *grpc.ClientConn) error {
+			_, err := jito_pb.NewSearcherServiceClient(conn).GetTipAccounts(ctx, &jito_pb.GetTipAccountsRequest{})
+			return err
+		}
+	}
+
+	return &RegionRanker{config: config}
+}
+
+// Rank probes every region concurrently and returns them sorted by round-trip time, unreachable regions last.
+func (r *RegionRanker) Rank(ctx context.Context) []RegionLatency {
+	results := make([]RegionLatency, 0, len(r.config.Endpoints))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for key, endpoint := range r.config.Endpoints {
+		wg.Add(1)
+		go func(key string, endpoint jito_go.JitoEndpointInfo) {
+			defer wg.Done()
+
+			rtt, err := r.probe(ctx, endpoint.BlockEngineURL)
+
+			mu.Lock()
+			results = append(results, RegionLatency{Key: key, Endpoint: endpoint, RTT: rtt, Err: err, Time: time.Now()})
+			mu.Unlock()
+		}(key, endpoint)
+	}
+	wg.Wait()
+
+	slices.SortFunc(results, func(a, b RegionLatency) int {
+		if (a.Err == nil) != (b.Err == nil) {
+			if a.Err == nil {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(cmp.Compare(a.RTT, b.RTT), strings.Compare(a.Key, b.Key))
+	})
+
+	r.mu.Lock()
+	r.ranking = results
+	hooks := slices.Clone(r.onRank)
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(slices.Clone(results))
+	}
+
+	return slices.Clone(results)
+}
+
+// probe dials target and returns the median duration of the probe calls. The first call is not timed
+// since it pays for the TCP and TLS handshakes.
+func (r *RegionRanker) probe(ctx context.Context, target string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))}, r.config.DialOptions...)
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err = r.config.Probe(ctx, conn); err != nil {
+		return 0, err
+	}
+
+	samples := make([]time.Duration, 0, r.config.Samples)
+	for i := 0; i < r.config.Samples; i++ {
+		start := time.Now()
+		if err = r.config.Probe(ctx, conn); err != nil {
+			return 0, err
+		}
+		samples = append(samples, time.Since(start))
+	}
+
+	slices.Sort(samples)
+	return samples[len(samples)/2], nil
+}
+
+// Start ranks the regions now and then every Interval until ctx is done.
+func (r *RegionRanker) Start(ctx context.Context) {
+	r.Rank(ctx)
+
+	go func() {
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Rank(ctx)
+			}
+		}
+	}()
+}
+
+// OnRank registers fn to be called with the new ranking after every probe round.
+func (r *RegionRanker) OnRank(fn func([]RegionLatency)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onRank = append(r.onRank, fn)
+}
+
+// Ranking returns the last ranking, nil if the regions were never probed.
+func (r *RegionRanker) Ranking() []RegionLatency {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.ranking)
+}
+
+// Fastest returns the fastest region of the last ranking.
+func (r *RegionRanker) Fastest() (jito_go.JitoEndpointInfo, error) {
+	endpoints, err := r.FastestN(1)
+	if err != nil {
+		return jito_go.JitoEndpointInfo{}, err
+	}
+
+	return endpoints[0], nil
+}
+
+// FastestN returns up to n reachable regions of the last ranking, fastest first. n must be positive.
+func (r *RegionRanker) FastestN(n int) ([]jito_go.JitoEndpointInfo, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid amount of regions %d, must be positive", n)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var endpoints []jito_go.JitoEndpointInfo
+	for _, region := range r.ranking {
+		if region.Err != nil || len(endpoints) == n {
+			break
+		}
+		endpoints = append(endpoints, region.Endpoint)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, ErrNoRegionAvailable
+	}
+
+	return endpoints, nil
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"testing"
+	"time"
+)
+
+func TestRegionRanker(t *testing.T) {
+	slow, fast := newHealthServer(t), newHealthServer(t)
+
+	ranker := NewRegionRanker(RegionRankerConfig{
+		Endpoints: map[string]jito_go.JitoEndpointInfo{
+			"SLOW": {BlockEngineURL: slow},
+			"FAST": {BlockEngineURL: fast},
+			"DOWN": {BlockEngineURL: "127.0.0.1:1"},
+		},
+		Timeout:     time.Second,
+		DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		Probe: func(ctx context.Context, conn *grpc.ClientConn) error {
+			if conn.Target() == slow {
+				time.Sleep(20 * time.Millisecond)
+			}
+			_, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			return err
+		},
+	})
+
+	_, err := ranker.Fastest()
+	assert.ErrorIs(t, err, ErrNoRegionAvailable)
+
+	var notified []RegionLatency
+	ranker.OnRank(func(ranking []RegionLatency) {
+		notified = ranking
+	})
+
+	ranking := ranker.Rank(context.Background())
+	if !assert.Len(t, ranking, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"FAST", "SLOW", "DOWN"}, []string{ranking[0].Key, ranking[1].Key, ranking[2].Key})
+	assert.Error(t, ranking[2].Err)
+	assert.Equal(t, ranking, notified)
+
+	endpoints, err := ranker.FastestN(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []jito_go.JitoEndpointInfo{{BlockEngineURL: fast}, {BlockEngineURL: slow}}, endpoints)
+
+	_, err = ranker.FastestN(0)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoRegionAvailable)
+}
@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/weeaa/jito-go/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+type ErrorClass int
+
+const (
+	// ErrorNone is the class of a nil error.
+	ErrorNone ErrorClass = iota
+	// ErrorRetryable errors are transient, the same call may succeed later.
+	ErrorRetryable
+	// ErrorPermanent errors will fail again if the call is repeated as is.
+	ErrorPermanent
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorNone:
+		return "none"
+	case ErrorRetryable:
+		return "retryable"
+	case ErrorPermanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifyError tells whether err, typically returned by a gRPC call, is worth retrying.
+// Unavailable, ResourceExhausted, DeadlineExceeded and Aborted are retryable,
+// every other status and a cancelled context are permanent.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorNone
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorPermanent
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorRetryable
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted:
+		return ErrorRetryable
+	default:
+		return ErrorPermanent
+	}
+}
+
+// IsRetryable is a shorthand for ClassifyError(err) == ErrorRetryable.
+func IsRetryable(err error) bool {
+	return ClassifyError(err) == ErrorRetryable
+}
+
+// IdempotentMethods are the read-only calls RetryUnaryInterceptor retries without AllowRetry.
+var IdempotentMethods = map[string]bool{
+	jito_pb.SearcherService_GetTipAccounts_FullMethodName:              true,
+	jito_pb.SearcherService_GetRegions_FullMethodName:                  true,
+	jito_pb.SearcherService_GetConnectedLeaders_FullMethodName:         true,
+	jito_pb.SearcherService_GetConnectedLeadersRegioned_FullMethodName: true,
+	jito_pb.SearcherService_GetNextScheduledLeader_FullMethodName:      true,
+	jito_pb.BlockEngineValidator_GetBlockBuilderFeeInfo_FullMethodName: true,
+	jito_pb.Relayer_GetTpuConfigs_FullMethodName:                       true,
+}
+
+type RetryConfig struct {
+	MaxAttempts int     // attempts including the first one, defaults to 3.
+	Backoff     Backoff // defaults to 100ms doubling up to 2s.
+
+	// Methods overrides IdempotentMethods, calls to other methods are only retried with AllowRetry.
+	Methods map[string]bool
+}
+
+// DefaultRetryConfig is a sensible configuration for WithRetryConfig, clients do not retry unless it is given.
+var DefaultRetryConfig = RetryConfig{
+	MaxAttempts: 3,
+	Backoff:     Backoff{Initial: 100 * time.Millisecond, Max: 2 * time.Second, Multiplier: 2},
+}
+
+// retryCallOption overrides the retry policy of a single call.
+type retryCallOption struct {
+	grpc.EmptyCallOption
+	allow bool
+}
+
+// AllowRetry opts a non idempotent call into retries, e.g. SendBundle when a duplicate bundle is acceptable.
+func AllowRetry() grpc.CallOption {
+	return retryCallOption{allow: true}
+}
+
+// DisableRetry prevents a call from being retried.
+func DisableRetry() grpc.CallOption {
+	return retryCallOption{allow: false}
+}
+
+// RetryUnaryInterceptor retries the unary calls failing with a retryable error, see ClassifyError,
+// waiting with a capped backoff between attempts. Only idempotent methods are retried unless AllowRetry is passed.
+func RetryUnaryInterceptor(config RetryConfig) grpc.UnaryClientInterceptor {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultRetryConfig.MaxAttempts
+	}
+	if config.Backoff.Initial <= 0 {
+		config.Backoff = DefaultRetryConfig.Backoff
+	}
+	if config.Methods == nil {
+		config.Methods = IdempotentMethods
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		retry := config.Methods[method]
+		for _, opt := range opts {
+			if o, ok := opt.(retryCallOption); ok {
+				retry = o.allow
+			}
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !retry {
+			return err
+		}
+
+		for attempt := 1; attempt < config.MaxAttempts && IsRetryable(err); attempt++ {
+			// the caller deadline is spent, another attempt would fail straight away
+			if ctx.Err() != nil {
+				return err
+			}
+
+			timer := time.NewTimer(config.Backoff.Duration(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		return err
+	}
+}
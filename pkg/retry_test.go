@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{nil, ErrorNone},
+		{context.Canceled, ErrorPermanent},
+		{context.DeadlineExceeded, ErrorRetryable},
+		{status.Error(codes.Unavailable, "unavailable"), ErrorRetryable},
+		{status.Error(codes.ResourceExhausted, "rate limited"), ErrorRetryable},
+		{status.Error(codes.Aborted, "aborted"), ErrorRetryable},
+		{status.Error(codes.InvalidArgument, "bad bundle"), ErrorPermanent},
+		{status.Error(codes.PermissionDenied, "denied"), ErrorPermanent},
+		{errors.New("unknown"), ErrorPermanent},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, ClassifyError(test.err), "%v", test.err)
+	}
+}
+
+func TestRetryUnaryInterceptor(t *testing.T) {
+	interceptor := RetryUnaryInterceptor(RetryConfig{
+		MaxAttempts: 3,
+		Backoff:     Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 2},
+	})
+
+	call := func(method string, code codes.Code, opts ...grpc.CallOption) int {
+		var attempts int
+		_ = interceptor(context.Background(), method, nil, nil, nil, func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+			attempts++
+			return status.Error(code, "failed")
+		}, opts...)
+		return attempts
+	}
+
+	assert.Equal(t, 3, call(jito_pb.SearcherService_GetTipAccounts_FullMethodName, codes.Unavailable))
+	assert.Equal(t, 1, call(jito_pb.SearcherService_GetTipAccounts_FullMethodName, codes.InvalidArgument))
+	assert.Equal(t, 1, call(jito_pb.SearcherService_GetTipAccounts_FullMethodName, codes.Unavailable, DisableRetry()))
+	assert.Equal(t, 1, call(jito_pb.SearcherService_SendBundle_FullMethodName, codes.Unavailable))
+	assert.Equal(t, 3, call(jito_pb.SearcherService_SendBundle_FullMethodName, codes.Unavailable, AllowRetry()))
+}
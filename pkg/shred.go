@@ -0,0 +1,305 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Layout of the shreds broadcast by Solana leaders, see the shred module of the Agave validator.
+const (
+	SizeOfShredSignature       = 64
+	SizeOfCommonShredHeader    = 83
+	SizeOfDataShredHeaders     = 88 // common header followed by the data header.
+	SizeOfCodingShredHeaders   = 89 // common header followed by the coding header.
+	SizeOfMerkleRoot           = 32
+	SizeOfMerkleProofEntry     = 20
+	LegacyShredPayloadSize     = 1228
+	MerkleCodeShredPayloadSize = 1228
+	MerkleDataShredPayloadSize = MerkleCodeShredPayloadSize - SizeOfCodingShredHeaders + SizeOfShredSignature
+)
+
+// MaxDataShredsPerFECSet is the maximum amount of data shreds of a merkle erasure set, see DATA_SHREDS_PER_FEC_BLOCK.
+const MaxDataShredsPerFECSet = 32
+
+var ErrInvalidShred = errors.New("invalid shred")
+
+type ShredType uint8
+
+const (
+	ShredTypeData ShredType = iota
+	ShredTypeCode
+)
+
+func (t ShredType) String() string {
+	if t == ShredTypeCode {
+		return "code"
+	}
+	return "data"
+}
+
+// ShredVariant is the byte following the shred signature, it tells the shred type and for merkle shreds the
+// size of the merkle proof and whether the shred is chained and resigned.
+type ShredVariant byte
+
+const (
+	ShredVariantLegacyCode ShredVariant = 0b0101_1010
+	ShredVariantLegacyData ShredVariant = 0b1010_0101
+)
+
+// ParseShredVariant checks b is a known shred variant.
+func ParseShredVariant(b byte) (ShredVariant, error) {
+	v := ShredVariant(b)
+	switch {
+	case v == ShredVariantLegacyCode, v == ShredVariantLegacyData:
+		return v, nil
+	}
+
+	switch b & 0xF0 {
+	case 0x40, 0x60, 0x70, 0x80, 0x90, 0xB0:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown variant %#x", ErrInvalidShred, b)
+	}
+}
+
+func (v ShredVariant) Type() ShredType {
+	if v == ShredVariantLegacyCode || (v != ShredVariantLegacyData && v&0xF0 < 0x80) {
+		return ShredTypeCode
+	}
+	return ShredTypeData
+}
+
+func (v ShredVariant) IsLegacy() bool {
+	return v == ShredVariantLegacyCode || v == ShredVariantLegacyData
+}
+
+// ProofSize is the amount of merkle proof entries of a merkle shred.
+func (v ShredVariant) ProofSize() int {
+	if v.IsLegacy() {
+		return 0
+	}
+	return int(v & 0x0F)
+}
+
+// Chained reports whether a merkle shred carries the merkle root of the previous erasure set.
+func (v ShredVariant) Chained() bool {
+	if v.IsLegacy() {
+		return false
+	}
+	high := v & 0xF0
+	return high == 0x60 || high == 0x70 || high == 0x90 || high == 0xB0
+}
+
+// Resigned reports whether a merkle shred carries a retransmitter signature.
+func (v ShredVariant) Resigned() bool {
+	if v.IsLegacy() {
+		return false
+	}
+	high := v & 0xF0
+	return high == 0x70 || high == 0xB0
+}
+
+func (v ShredVariant) String() string {
+	if v.IsLegacy() {
+		return "legacy " + v.Type().String()
+	}
+
+	s := fmt.Sprintf("merkle %s (proof %d", v.Type(), v.ProofSize())
+	if v.Chained() {
+		s += ", chained"
+	}
+	if v.Resigned() {
+		s += ", resigned"
+	}
+	return s + ")"
+}
+
+// PayloadSize is the size of a shred of variant v, excluding the trailing nonce of repair responses.
+func (v ShredVariant) PayloadSize() int {
+	if v.IsLegacy() || v.Type() == ShredTypeCode {
+		return LegacyShredPayloadSize
+	}
+	return MerkleDataShredPayloadSize
+}
+
+// Capacity is the size of the data buffer of a data shred, or of the parity of a coding shred.
+func (v ShredVariant) Capacity() int {
+	switch v {
+	case ShredVariantLegacyData:
+		// the first bytes of legacy data shreds, headers included, are erasure coded into the parity of coding shreds
+		return LegacyShredPayloadSize - SizeOfCodingShredHeaders - SizeOfDataShredHeaders
+	case ShredVariantLegacyCode:
+		return LegacyShredPayloadSize - SizeOfCodingShredHeaders
+	}
+
+	headers := SizeOfDataShredHeaders
+	if v.Type() == ShredTypeCode {
+		headers = SizeOfCodingShredHeaders
+	}
+
+	capacity := v.PayloadSize() - headers - v.ProofSize()*SizeOfMerkleProofEntry
+	if v.Chained() {
+		capacity -= SizeOfMerkleRoot
+	}
+	if v.Resigned() {
+		capacity -= SizeOfShredSignature
+	}
+	return capacity
+}
+
+// ShredFlags are the flags of a data shred.
+type ShredFlags uint8
+
+const (
+	ShredFlagDataComplete  ShredFlags = 0b0100_0000
+	ShredFlagLastInSlot    ShredFlags = 0b1100_0000
+	ShredTickReferenceMask ShredFlags = 0b0011_1111
+)
+
+// DataComplete reports whether the shred ends a batch of entries.
+func (f ShredFlags) DataComplete() bool {
+	return f&ShredFlagDataComplete != 0
+}
+
+// LastInSlot reports whether the shred is the last data shred of its slot.
+func (f ShredFlags) LastInSlot() bool {
+	return f&ShredFlagLastInSlot == ShredFlagLastInSlot
+}
+
+// ReferenceTick is the tick of the slot at which the shred was made.
+func (f ShredFlags) ReferenceTick() uint8 {
+	return uint8(f & ShredTickReferenceMask)
+}
+
+// DataShredHeader follows the common header of data shreds.
+type DataShredHeader struct {
+	ParentOffset uint16 // slot minus the parent slot.
+	Flags        ShredFlags
+	Size         uint16 // size of the headers and the data of the shred.
+}
+
+// CodingShredHeader follows the common header of coding shreds.
+type CodingShredHeader struct {
+	NumDataShreds   uint16 // data shreds of the erasure set.
+	NumCodingShreds uint16 // coding shreds of the erasure set.
+	Position        uint16 // position of the shred among the coding shreds of the erasure set.
+}
+
+// Shred is a shred with its headers decoded, Data is set for data shreds and Code for coding shreds.
+type Shred struct {
+	Signature   solana.Signature
+	Variant     ShredVariant
+	Slot        uint64
+	Index       uint32
+	Version     uint16
+	FECSetIndex uint32
+
+	Data *DataShredHeader
+	Code *CodingShredHeader
+
+	Payload []byte // the shred, trimmed to the payload size of its variant.
+}
+
+// ParseShred decodes the headers of the shred payload, which is retained by the returned Shred.
+func ParseShred(payload []byte) (Shred, error) {
+	if len(payload) < SizeOfCommonShredHeader {
+		return Shred{}, fmt.Errorf("%w: %d bytes is too short", ErrInvalidShred, len(payload))
+	}
+
+	variant, err := ParseShredVariant(payload[64])
+	if err != nil {
+		return Shred{}, err
+	}
+
+	size := variant.PayloadSize()
+	if variant == ShredVariantLegacyData {
+		// legacy data shreds may come without their zero padding
+		size = min(len(payload), size)
+	}
+	if len(payload) < size {
+		return Shred{}, fmt.Errorf("%w: %d bytes for a %s shred of %d bytes", ErrInvalidShred, len(payload), variant, size)
+	}
+
+	shred := Shred{
+		Signature:   solana.SignatureFromBytes(payload[:SizeOfShredSignature]),
+		Variant:     variant,
+		Slot:        binary.LittleEndian.Uint64(payload[65:73]),
+		Index:       binary.LittleEndian.Uint32(payload[73:77]),
+		Version:     binary.LittleEndian.Uint16(payload[77:79]),
+		FECSetIndex: binary.LittleEndian.Uint32(payload[79:83]),
+		Payload:     payload[:size],
+	}
+
+	if variant.Type() == ShredTypeCode {
+		if len(payload) < SizeOfCodingShredHeaders {
+			return Shred{}, fmt.Errorf("%w: coding shred headers truncated", ErrInvalidShred)
+		}
+		shred.Code = &CodingShredHeader{
+			NumDataShreds:   binary.LittleEndian.Uint16(payload[83:85]),
+			NumCodingShreds: binary.LittleEndian.Uint16(payload[85:87]),
+			Position:        binary.LittleEndian.Uint16(payload[87:89]),
+		}
+		if err = shred.sanitizeCode(); err != nil {
+			return Shred{}, err
+		}
+		return shred, nil
+	}
+
+	if len(payload) < SizeOfDataShredHeaders {
+		return Shred{}, fmt.Errorf("%w: data shred headers truncated", ErrInvalidShred)
+	}
+	shred.Data = &DataShredHeader{
+		ParentOffset: binary.LittleEndian.Uint16(payload[83:85]),
+		Flags:        ShredFlags(payload[85]),
+		Size:         binary.LittleEndian.Uint16(payload[86:88]),
+	}
+	if err = shred.sanitizeData(); err != nil {
+		return Shred{}, err
+	}
+	return shred, nil
+}
+
+func (s Shred) sanitizeData() error {
+	size := int(s.Data.Size)
+	if size < SizeOfDataShredHeaders || size > SizeOfDataShredHeaders+s.Variant.Capacity() || size > len(s.Payload) {
+		return fmt.Errorf("%w: data size %d out of bounds", ErrInvalidShred, size)
+	}
+	if s.Index < s.FECSetIndex {
+		return fmt.Errorf("%w: index %d below fec set index %d", ErrInvalidShred, s.Index, s.FECSetIndex)
+	}
+	if uint64(s.Data.ParentOffset) > s.Slot || (s.Data.ParentOffset == 0 && s.Slot != 0) {
+		return fmt.Errorf("%w: parent offset %d of slot %d", ErrInvalidShred, s.Data.ParentOffset, s.Slot)
+	}
+	return nil
+}
+
+func (s Shred) sanitizeCode() error {
+	if s.Code.NumDataShreds == 0 || s.Code.NumCodingShreds == 0 {
+		return fmt.Errorf("%w: empty erasure set", ErrInvalidShred)
+	}
+	if s.Code.Position >= s.Code.NumCodingShreds {
+		return fmt.Errorf("%w: coding position %d out of %d", ErrInvalidShred, s.Code.Position, s.Code.NumCodingShreds)
+	}
+	if !s.Variant.IsLegacy() && s.Code.NumDataShreds > MaxDataShredsPerFECSet {
+		return fmt.Errorf("%w: %d data shreds in a merkle erasure set", ErrInvalidShred, s.Code.NumDataShreds)
+	}
+	return nil
+}
+
+// ParentSlot returns the parent slot of a data shred, 0 for coding shreds.
+func (s Shred) ParentSlot() uint64 {
+	if s.Data == nil {
+		return 0
+	}
+	return s.Slot - uint64(s.Data.ParentOffset)
+}
+
+// DataPayload returns the entry bytes carried by a data shred, nil for coding shreds.
+func (s Shred) DataPayload() []byte {
+	if s.Data == nil {
+		return nil
+	}
+	return s.Payload[SizeOfDataShredHeaders:s.Data.Size]
+}
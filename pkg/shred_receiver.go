@@ -0,0 +1,225 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/hashicorp/golang-lru/v2"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+type ShredReceiverConfig struct {
+	SlotBuffer  int           // shreds buffered for every slot before being dropped, defaults to 4096.
+	MaxSlots    int           // slots streamed at once, the lowest one is closed beyond it, defaults to 16.
+	SlotTimeout time.Duration // a slot is closed once none of its shreds was received for that long, defaults to 2s.
+	ReadBuffer  int           // size of the socket receive buffer, defaults to 32MiB.
+
+	Events *EventBus // receives a DroppedEvent when shreds are dropped, may be nil.
+}
+
+// ShredReceiverStats holds the counters of a ShredReceiver, it is safe for concurrent use.
+type ShredReceiverStats struct {
+	Received atomic.Uint64 // packets read from the socket.
+	Invalid  atomic.Uint64 // packets which are not valid shreds.
+	Late     atomic.Uint64 // shreds of slots already closed.
+	Dropped  atomic.Uint64 // shreds dropped because their slot consumer is too slow.
+}
+
+// SlotShreds streams the shreds of a single slot, Shreds is closed once the slot times out or is evicted.
+type SlotShreds struct {
+	Slot   uint64
+	Shreds <-chan Shred
+}
+
+type slotStream struct {
+	shreds   chan Shred
+	lastSeen time.Time
+}
+
+// ShredReceiver reads shreds from a UDP socket, e.g. the one advertised with ShredStream heartbeats, and streams
+// them per slot. Invalid packets are skipped. Shreds are never waited for: they are dropped when the consumer of
+// their slot lags behind.
+type ShredReceiver struct {
+	conn   net.PacketConn
+	config ShredReceiverConfig
+	stats  ShredReceiverStats
+
+	slots  chan SlotShreds
+	open   map[uint64]*slotStream
+	closed *lru.Cache[uint64, struct{}]
+}
+
+// ListenShreds listens on the UDP address addr, e.g. ":20000", and starts receiving shreds until ctx is done.
+func ListenShreds(ctx context.Context, addr string, config ShredReceiverConfig) (*ShredReceiver, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewShredReceiver(conn, config)
+	r.Start(ctx)
+	return r, nil
+}
+
+// NewShredReceiver reads shreds from conn, which is closed once the receiver stops.
+func NewShredReceiver(conn net.PacketConn, config ShredReceiverConfig) *ShredReceiver {
+	if config.SlotBuffer <= 0 {
+		config.SlotBuffer = 4096
+	}
+	if config.MaxSlots <= 0 {
+		config.MaxSlots = 16
+	}
+	if config.SlotTimeout <= 0 {
+		config.SlotTimeout = 2 * time.Second
+	}
+	if config.ReadBuffer <= 0 {
+		config.ReadBuffer = 32 << 20
+	}
+
+	if udp, ok := conn.(*net.UDPConn); ok {
+		// best effort, the kernel may cap it
+		udp.SetReadBuffer(config.ReadBuffer)
+	}
+
+	closed, _ := lru.New[uint64, struct{}](1024)
+	return &ShredReceiver{
+		conn:   conn,
+		config: config,
+		slots:  make(chan SlotShreds, config.MaxSlots),
+		open:   make(map[uint64]*slotStream),
+		closed: closed,
+	}
+}
+
+// Slots yields a SlotShreds for every slot as soon as its first shred is received, it is closed once the receiver stops.
+func (r *ShredReceiver) Slots() <-chan SlotShreds {
+	return r.slots
+}
+
+// Stats returns the counters of the receiver.
+func (r *ShredReceiver) Stats() *ShredReceiverStats {
+	return &r.stats
+}
+
+// Addr returns the address the receiver listens on.
+func (r *ShredReceiver) Addr() net.Addr {
+	return r.conn.LocalAddr()
+}
+
+// Start receives shreds in the background until ctx is done.
+func (r *ShredReceiver) Start(ctx context.Context) {
+	go r.Run(ctx)
+}
+
+// Run receives shreds until ctx is done or the socket fails, it is the blocking counterpart of Start.
+func (r *ShredReceiver) Run(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() { r.conn.Close() })
+	defer stop()
+	defer r.conn.Close()
+	defer r.closeAll()
+
+	// the read deadline wakes the loop up to close idle slots while no shred is received
+	sweepInterval := r.config.SlotTimeout / 2
+	lastSweep := time.Now()
+
+	buf := make([]byte, 2048)
+	for {
+		r.conn.SetReadDeadline(time.Now().Add(sweepInterval))
+		n, _, err := r.conn.ReadFrom(buf)
+
+		var netErr net.Error
+		switch {
+		case err == nil:
+			r.stats.Received.Add(1)
+			r.handle(append([]byte(nil), buf[:n]...))
+		case errors.As(err, &netErr) && netErr.Timeout():
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			return err
+		}
+
+		if now := time.Now(); now.Sub(lastSweep) >= sweepInterval {
+			r.sweep(now)
+			lastSweep = now
+		}
+	}
+}
+
+func (r *ShredReceiver) handle(payload []byte) {
+	shred, err := ParseShred(payload)
+	if err != nil {
+		r.stats.Invalid.Add(1)
+		return
+	}
+
+	stream, ok := r.open[shred.Slot]
+	if !ok {
+		if stream = r.openSlot(shred.Slot); stream == nil {
+			return
+		}
+	}
+	stream.lastSeen = time.Now()
+
+	select {
+	case stream.shreds <- shred:
+	default:
+		r.config.Events.Publish(DroppedEvent{Stream: "shreds", Total: r.stats.Dropped.Add(1), Time: time.Now()})
+	}
+}
+
+// openSlot starts streaming slot, it returns nil when the slot was already closed or is older than every open slot
+// while the receiver is full, or when the slots are not consumed.
+func (r *ShredReceiver) openSlot(slot uint64) *slotStream {
+	if r.closed.Contains(slot) {
+		r.stats.Late.Add(1)
+		return nil
+	}
+
+	if len(r.open) >= r.config.MaxSlots {
+		lowest := slot
+		for open := range r.open {
+			lowest = min(lowest, open)
+		}
+		if lowest == slot {
+			r.stats.Late.Add(1)
+			return nil
+		}
+		r.closeSlot(lowest)
+	}
+
+	stream := &slotStream{shreds: make(chan Shred, r.config.SlotBuffer)}
+	select {
+	case r.slots <- SlotShreds{Slot: slot, Shreds: stream.shreds}:
+	default:
+		// nobody consumes the slots, the whole slot is dropped
+		r.closed.Add(slot, struct{}{})
+		r.config.Events.Publish(DroppedEvent{Stream: "shreds", Total: r.stats.Dropped.Add(1), Time: time.Now()})
+		return nil
+	}
+
+	r.open[slot] = stream
+	return stream
+}
+
+func (r *ShredReceiver) closeSlot(slot uint64) {
+	close(r.open[slot].shreds)
+	delete(r.open, slot)
+	r.closed.Add(slot, struct{}{})
+}
+
+func (r *ShredReceiver) sweep(now time.Time) {
+	for slot, stream := range r.open {
+		if now.Sub(stream.lastSeen) >= r.config.SlotTimeout {
+			r.closeSlot(slot)
+		}
+	}
+}
+
+func (r *ShredReceiver) closeAll() {
+	for slot := range r.open {
+		r.closeSlot(slot)
+	}
+	close(r.slots)
+}
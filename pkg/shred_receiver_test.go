@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestShredReceiver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	receiver := NewShredReceiver(conn, ShredReceiverConfig{MaxSlots: 2, SlotTimeout: 100 * time.Millisecond})
+	receiver.Start(ctx)
+
+	sender, err := net.Dial("udp", receiver.Addr().String())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer sender.Close()
+
+	replay := func(payloads ...[]byte) {
+		for _, payload := range payloads {
+			if _, err := sender.Write(payload); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	nextSlot := func() SlotShreds {
+		select {
+		case slot := <-receiver.Slots():
+			return slot
+		case <-ctx.Done():
+			t.Fatal("no slot received")
+			return SlotShreds{}
+		}
+	}
+
+	replay(
+		newTestDataShred(0x86, 10, 0, 0, 0, []byte("entries")),
+		[]byte("not a shred"),
+		newTestCodeShred(0x46, 10, 0, 0, 1, 1, 0, nil),
+	)
+
+	first := nextSlot()
+	assert.Equal(t, uint64(10), first.Slot)
+
+	data := <-first.Shreds
+	assert.Equal(t, ShredTypeData, data.Variant.Type())
+	assert.Equal(t, []byte("entries"), data.DataPayload())
+
+	code := <-first.Shreds
+	assert.Equal(t, ShredTypeCode, code.Variant.Type())
+	assert.Equal(t, uint64(10), code.Slot)
+
+	// a third slot evicts the lowest one, its late shreds are then skipped
+	replay(newTestDataShred(0x86, 11, 0, 0, 0, nil), newTestDataShred(0x86, 12, 0, 0, 0, nil))
+	assert.Equal(t, uint64(11), nextSlot().Slot)
+	third := nextSlot()
+	assert.Equal(t, uint64(12), third.Slot)
+
+	_, ok := <-first.Shreds
+	assert.False(t, ok)
+
+	replay(newTestDataShred(0x86, 10, 1, 0, 0, nil))
+	assert.Eventually(t, func() bool { return receiver.Stats().Late.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), receiver.Stats().Invalid.Load())
+	assert.Equal(t, uint64(6), receiver.Stats().Received.Load())
+
+	// idle slots are closed once they time out
+	<-third.Shreds
+	select {
+	case _, ok = <-third.Shreds:
+		assert.False(t, ok)
+	case <-ctx.Done():
+		t.Fatal("idle slot not closed")
+	}
+
+	cancel()
+	for range receiver.Slots() {
+	}
+}
@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestShredHeader(variant ShredVariant, slot uint64, index, fecSetIndex uint32) []byte {
+	payload := make([]byte, variant.PayloadSize())
+	for i := range SizeOfShredSignature {
+		payload[i] = byte(slot) + byte(fecSetIndex)
+	}
+	payload[64] = byte(variant)
+	binary.LittleEndian.PutUint64(payload[65:], slot)
+	binary.LittleEndian.PutUint32(payload[73:], index)
+	binary.LittleEndian.PutUint16(payload[77:], 50093)
+	binary.LittleEndian.PutUint32(payload[79:], fecSetIndex)
+	return payload
+}
+
+// newTestDataShred lays out a data shred the way leaders do, data must fit the capacity of variant.
+func newTestDataShred(variant ShredVariant, slot uint64, index, fecSetIndex uint32, flags ShredFlags, data []byte) []byte {
+	payload := newTestShredHeader(variant, slot, index, fecSetIndex)
+	binary.LittleEndian.PutUint16(payload[83:], 1)
+	payload[85] = byte(flags)
+	binary.LittleEndian.PutUint16(payload[86:], uint16(SizeOfDataShredHeaders+len(data)))
+	copy(payload[SizeOfDataShredHeaders:], data)
+	return payload
+}
+
+// newTestCodeShred lays out a coding shred, parity is copied after the headers.
+func newTestCodeShred(variant ShredVariant, slot uint64, index, fecSetIndex uint32, numData, numCoding, position uint16, parity []byte) []byte {
+	payload := newTestShredHeader(variant, slot, index, fecSetIndex)
+	binary.LittleEndian.PutUint16(payload[83:], numData)
+	binary.LittleEndian.PutUint16(payload[85:], numCoding)
+	binary.LittleEndian.PutUint16(payload[87:], position)
+	copy(payload[SizeOfCodingShredHeaders:], parity)
+	return payload
+}
+
+// loadTestShreds parses the shreds of testdata/name.bin.gz, a gzip stream of shreds each prefixed by its
+// little-endian uint16 size, see testdata/gen_shreds.go.
+func loadTestShreds(t *testing.T, name string) []Shred {
+	f, err := os.Open(filepath.Join("testdata", name+".bin.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var shreds []Shred
+	for len(data) > 0 {
+		size := int(binary.LittleEndian.Uint16(data))
+		shred, err := ParseShred(data[2 : 2+size])
+		if err != nil {
+			t.Fatalf("shred %d of %s: %v", len(shreds), name, err)
+		}
+		shreds = append(shreds, shred)
+		data = data[2+size:]
+	}
+	return shreds
+}
+
+func TestShredVariant(t *testing.T) {
+	tests := []struct {
+		variant   byte
+		shredType ShredType
+		legacy    bool
+		proofSize int
+		chained   bool
+		resigned  bool
+		capacity  int
+	}{
+		{0xA5, ShredTypeData, true, 0, false, false, 1051},
+		{0x5A, ShredTypeCode, true, 0, false, false, 1139},
+		{0x86, ShredTypeData, false, 6, false, false, 995},
+		{0x96, ShredTypeData, false, 6, true, false, 963},
+		{0xB6, ShredTypeData, false, 6, true, true, 899},
+		{0x46, ShredTypeCode, false, 6, false, false, 1019},
+		{0x66, ShredTypeCode, false, 6, true, false, 987},
+		{0x76, ShredTypeCode, false, 6, true, true, 923},
+	}
+
+	for _, tt := range tests {
+		variant, err := ParseShredVariant(tt.variant)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, tt.shredType, variant.Type(), variant.String())
+		assert.Equal(t, tt.legacy, variant.IsLegacy(), variant.String())
+		assert.Equal(t, tt.proofSize, variant.ProofSize(), variant.String())
+		assert.Equal(t, tt.chained, variant.Chained(), variant.String())
+		assert.Equal(t, tt.resigned, variant.Resigned(), variant.String())
+		assert.Equal(t, tt.capacity, variant.Capacity(), variant.String())
+	}
+
+	for _, b := range []byte{0x00, 0x50, 0xA0, 0xC6, 0xFF} {
+		_, err := ParseShredVariant(b)
+		assert.ErrorIs(t, err, ErrInvalidShred)
+	}
+}
+
+func TestParseShred(t *testing.T) {
+	data := []byte("entries")
+
+	for _, variant := range []ShredVariant{ShredVariantLegacyData, 0x86, 0x96, 0xB6} {
+		payload := newTestDataShred(variant, 300, 37, 32, ShredFlagLastInSlot|3, data)
+		// repair responses come with a trailing nonce
+		shred, err := ParseShred(append(payload, 1, 2, 3, 4))
+		if !assert.NoError(t, err, variant.String()) {
+			continue
+		}
+
+		assert.Equal(t, variant, shred.Variant)
+		assert.Equal(t, payload[:64], shred.Signature[:])
+		assert.Equal(t, uint64(300), shred.Slot)
+		assert.Equal(t, uint32(37), shred.Index)
+		assert.Equal(t, uint16(50093), shred.Version)
+		assert.Equal(t, uint32(32), shred.FECSetIndex)
+		assert.Nil(t, shred.Code)
+		assert.Equal(t, uint64(299), shred.ParentSlot())
+		assert.True(t, shred.Data.Flags.DataComplete())
+		assert.True(t, shred.Data.Flags.LastInSlot())
+		assert.Equal(t, uint8(3), shred.Data.Flags.ReferenceTick())
+		assert.Equal(t, data, shred.DataPayload())
+		assert.Len(t, shred.Payload, variant.PayloadSize())
+	}
+
+	for _, variant := range []ShredVariant{ShredVariantLegacyCode, 0x46, 0x66, 0x76} {
+		shred, err := ParseShred(newTestCodeShred(variant, 300, 40, 32, 32, 32, 8, nil))
+		if !assert.NoError(t, err, variant.String()) {
+			continue
+		}
+
+		assert.Equal(t, uint32(40), shred.Index)
+		assert.Nil(t, shred.Data)
+		assert.Nil(t, shred.DataPayload())
+		assert.Equal(t, CodingShredHeader{NumDataShreds: 32, NumCodingShreds: 32, Position: 8}, *shred.Code)
+	}
+
+	invalid := map[string][]byte{
+		"short":            make([]byte, 80),
+		"unknown variant":  newTestShredHeader(0x86, 1, 0, 0)[:100],
+		"truncated merkle": newTestDataShred(0x86, 300, 0, 0, 0, data)[:1000],
+		"oversized data":   newTestDataShred(0x86, 300, 0, 0, 0, make([]byte, 996)),
+		"index below fec":  newTestDataShred(0x86, 300, 0, 32, 0, data),
+		"bad position":     newTestCodeShred(0x46, 300, 0, 0, 32, 32, 32, nil),
+		"empty erasure":    newTestCodeShred(0x46, 300, 0, 0, 0, 32, 0, nil),
+	}
+	invalid["unknown variant"][64] = 0xC6
+
+	for name, payload := range invalid {
+		_, err := ParseShred(payload)
+		assert.ErrorIs(t, err, ErrInvalidShred, name)
+	}
+}
+
+func TestParseShredTestdata(t *testing.T) {
+	type set struct {
+		name               string
+		data, code         ShredVariant
+		numData, numCoding int
+		fecSetIndex        uint32
+		codeIndex          uint32
+		flags              ShredFlags // of the last data shred.
+		dataSize           uint16     // size of the data shreds but the last.
+	}
+	sets := []set{
+		{"merkle_chained", 0x95, 0x65, 7, 21, 0, 0, ShredFlagDataComplete | 41, 1071},
+		{"merkle_chained_resigned", 0xB5, 0x75, 3, 19, 7, 21, ShredFlagLastInSlot | 63, 1007},
+		{"legacy", ShredVariantLegacyData, ShredVariantLegacyCode, 3, 3, 0, 0, ShredFlagLastInSlot | 12, 1139},
+	}
+
+	for _, want := range sets {
+		shreds := loadTestShreds(t, want.name)
+		if !assert.Len(t, shreds, want.numData+want.numCoding, want.name) {
+			continue
+		}
+
+		slot := uint64(310_000_000)
+		if want.data.IsLegacy() {
+			slot = 150_000_000
+		}
+		for i, shred := range shreds {
+			assert.Equal(t, slot, shred.Slot, want.name)
+			assert.Equal(t, uint16(50093), shred.Version, want.name)
+			assert.Equal(t, want.fecSetIndex, shred.FECSetIndex, want.name)
+
+			if i < want.numData {
+				assert.Equal(t, want.data, shred.Variant, want.name)
+				assert.Equal(t, want.fecSetIndex+uint32(i), shred.Index, want.name)
+				assert.Equal(t, slot-1, shred.ParentSlot(), want.name)
+				assert.Len(t, shred.Payload, want.data.PayloadSize(), want.name)
+				if i == want.numData-1 {
+					assert.Equal(t, want.flags, shred.Data.Flags, want.name)
+				} else {
+					assert.Equal(t, want.flags.ReferenceTick(), shred.Data.Flags.ReferenceTick(), want.name)
+					assert.False(t, shred.Data.Flags.DataComplete(), want.name)
+					assert.Equal(t, want.dataSize, shred.Data.Size, want.name)
+				}
+				continue
+			}
+
+			position := i - want.numData
+			assert.Equal(t, want.code, shred.Variant, want.name)
+			assert.Equal(t, want.codeIndex+uint32(position), shred.Index, want.name)
+			assert.Equal(t, CodingShredHeader{NumDataShreds: uint16(want.numData), NumCodingShreds: uint16(want.numCoding), Position: uint16(position)}, *shred.Code, want.name)
+			assert.Nil(t, shred.DataPayload(), want.name)
+		}
+
+		seed := sha256.Sum256([]byte("leader"))
+		leader := ed25519.NewKeyFromSeed(seed[:]).Public().(ed25519.PublicKey)
+		for _, shred := range shreds {
+			if want.data.IsLegacy() {
+				// legacy shreds are signed whole
+				assert.True(t, ed25519.Verify(leader, shred.Payload[SizeOfShredSignature:], shred.Signature[:]), want.name)
+			} else {
+				// merkle shreds are signed over the root of their erasure set
+				assert.Equal(t, shreds[0].Signature, shred.Signature, want.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamStalled is reported when no message has been received within the heartbeat timeout.
+var ErrStreamStalled = errors.New("stream stalled: heartbeat timeout")
+
+// Receiver is implemented by every server streaming gRPC client generated in jito_pb.
+type Receiver[T any] interface {
+	Recv() (T, error)
+}
+
+// StreamOpener opens a new stream, the stream must be bound to ctx.
+type StreamOpener[T any] func(ctx context.Context) (Receiver[T], error)
+
+type StreamEventKind int
+
+const (
+	StreamConnected StreamEventKind = iota
+	StreamDisconnected
+	StreamReconnecting
+	StreamStalled
+	StreamClosed
+)
+
+func (k StreamEventKind) String() string {
+	switch k {
+	case StreamConnected:
+		return "connected"
+	case StreamDisconnected:
+		return "disconnected"
+	case StreamReconnecting:
+		return "reconnecting"
+	case StreamStalled:
+		return "stalled"
+	case StreamClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StreamEvent describes a lifecycle change of a ResilientStream.
+type StreamEvent struct {
+	Stream  string // name given with WithEventBus.
+	Kind    StreamEventKind
+	Err     error
+	Attempt int // reconnection attempt, 0 for the initial stream.
+	Time    time.Time
+}
+
+// Backoff computes exponential delays between reconnection attempts.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// DefaultBackoff is used by streams and retries when no Backoff is provided.
+var DefaultBackoff = Backoff{
+	Initial:    250 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+}
+
+// Duration returns the delay to wait before the given attempt (starting at 1).
+func (b Backoff) Duration(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+
+	d := float64(b.Initial)
+	for i := 1; i < attempt; i++ {
+		d *= b.Multiplier
+		if time.Duration(d) >= b.Max {
+			return b.Max
+		}
+	}
+
+	return time.Duration(d)
+}
+
+// OverflowPolicy decides what happens to a message when the consumer is too slow and the buffer is full.
+type OverflowPolicy int
+
+const (
+	// Block waits for the consumer, which stalls the gRPC receive loop.
+	Block OverflowPolicy = iota
+	// DropOldest discards the oldest buffered message to make room for the new one.
+	DropOldest
+	// DropNewest discards the new message.
+	DropNewest
+)
+
+// StreamStats holds the counters of a ResilientStream, it is safe for concurrent use.
+type StreamStats struct {
+	Received   atomic.Uint64 // messages received from the server, including dropped ones.
+	Dropped    atomic.Uint64
+	Reconnects atomic.Uint64
+}
+
+type StreamConfig struct {
+	Backoff          Backoff
+	HeartbeatTimeout time.Duration // If no message is received within HeartbeatTimeout the stream is re-opened, 0 disables it.
+	BufferSize       int
+	OverflowPolicy   OverflowPolicy // Only applies once the buffer is full.
+	Stats            *StreamStats
+	Events           *EventBus // also receives the stream events and the dropped messages, may be nil.
+	Name             string
+}
+
+type StreamOption func(*StreamConfig)
+
+// WithBackoff sets the backoff used between reconnection attempts.
+func WithBackoff(backoff Backoff) StreamOption {
+	return func(c *StreamConfig) {
+		c.Backoff = backoff
+	}
+}
+
+// WithBuffer sets the size of the data channel buffer and what to do once it is full.
+func WithBuffer(size int, policy OverflowPolicy) StreamOption {
+	return func(c *StreamConfig) {
+		c.BufferSize = size
+		c.OverflowPolicy = policy
+	}
+}
+
+// WithStats makes the stream report its counters to stats.
+func WithStats(stats *StreamStats) StreamOption {
+	return func(c *StreamConfig) {
+		c.Stats = stats
+	}
+}
+
+// WithEventBus publishes the events of the stream and its dropped messages on bus, name identifies the stream.
+func WithEventBus(bus *EventBus, name string) StreamOption {
+	return func(c *StreamConfig) {
+		c.Events = bus
+		c.Name = name
+	}
+}
+
+// WithHeartbeatTimeout sets the duration after which a silent stream is considered dead.
+func WithHeartbeatTimeout(timeout time.Duration) StreamOption {
+	return func(c *StreamConfig) {
+		c.HeartbeatTimeout = timeout
+	}
+}
+
+// ResilientStream receives messages from a gRPC stream and transparently re-opens it with backoff
+// whenever it fails or stalls. Messages are delivered on Data, lifecycle changes on Events and errors on Errors.
+// All channels are closed once ctx is done.
+type ResilientStream[T any] struct {
+	open   StreamOpener[T]
+	config StreamConfig
+
+	data   chan T
+	events chan StreamEvent
+	errs   chan error
+	done   chan struct{}
+
+	stats *StreamStats
+}
+
+type recvResult[T any] struct {
+	msg T
+	err error
+}
+
+// NewResilientStream opens the first stream synchronously, returning its error if any, and keeps it alive until ctx is done.
+func NewResilientStream[T any](ctx context.Context, open StreamOpener[T], opts ...StreamOption) (*ResilientStream[T], error) {
+	config := StreamConfig{Backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.Stats == nil {
+		config.Stats = &StreamStats{}
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	recv, err := open(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &ResilientStream[T]{
+		open:   open,
+		config: config,
+		data:   make(chan T, max(config.BufferSize, 0)),
+		events: make(chan StreamEvent, 16),
+		errs:   make(chan error, 16),
+		done:   make(chan struct{}),
+		stats:  config.Stats,
+	}
+
+	go s.run(ctx, streamCtx, cancel, recv)
+
+	return s, nil
+}
+
+// Data returns the channel on which stream messages are delivered.
+func (s *ResilientStream[T]) Data() <-chan T {
+	return s.data
+}
+
+// Events returns the lifecycle events channel. It is buffered and events are dropped if it is not drained.
+func (s *ResilientStream[T]) Events() <-chan StreamEvent {
+	return s.events
+}
+
+// Errors returns the errors channel. It is buffered and errors are dropped if it is not drained.
+func (s *ResilientStream[T]) Errors() <-chan error {
+	return s.errs
+}
+
+// Done is closed once the stream has stopped and every channel is closed.
+func (s *ResilientStream[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Reconnects returns the amount of times the stream has been re-opened.
+func (s *ResilientStream[T]) Reconnects() uint64 {
+	return s.stats.Reconnects.Load()
+}
+
+// Dropped returns the amount of messages discarded by the overflow policy.
+func (s *ResilientStream[T]) Dropped() uint64 {
+	return s.stats.Dropped.Load()
+}
+
+func (s *ResilientStream[T]) run(ctx, streamCtx context.Context, cancel context.CancelFunc, recv Receiver[T]) {
+	defer close(s.done)
+	defer close(s.errs)
+	defer close(s.events)
+	defer close(s.data)
+
+	s.emit(StreamEvent{Kind: StreamConnected})
+
+	for {
+		err := s.consume(ctx, streamCtx, recv)
+		cancel()
+
+		if ctx.Err() != nil {
+			s.emit(StreamEvent{Kind: StreamClosed, Err: ctx.Err()})
+			return
+		}
+
+		if errors.Is(err, ErrStreamStalled) {
+			s.emit(StreamEvent{Kind: StreamStalled, Err: err})
+		} else {
+			s.emit(StreamEvent{Kind: StreamDisconnected, Err: err})
+		}
+		s.dispatchErr(err)
+
+		streamCtx, cancel, recv = s.reopen(ctx)
+		if recv == nil {
+			s.emit(StreamEvent{Kind: StreamClosed, Err: ctx.Err()})
+			return
+		}
+	}
+}
+
+// reopen opens a new stream with backoff until it succeeds or ctx is done, in which case recv is nil.
+func (s *ResilientStream[T]) reopen(ctx context.Context) (context.Context, context.CancelFunc, Receiver[T]) {
+	for attempt := 1; ; attempt++ {
+		s.emit(StreamEvent{Kind: StreamReconnecting, Attempt: attempt})
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, nil
+		case <-time.After(s.config.Backoff.Duration(attempt)):
+		}
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		recv, err := s.open(streamCtx)
+		if err != nil {
+			cancel()
+			s.dispatchErr(err)
+			continue
+		}
+
+		s.stats.Reconnects.Add(1)
+		s.emit(StreamEvent{Kind: StreamConnected, Attempt: attempt})
+
+		return streamCtx, cancel, recv
+	}
+}
+
+// consume delivers messages of a single stream until it fails, stalls or ctx is done.
+func (s *ResilientStream[T]) consume(ctx, streamCtx context.Context, recv Receiver[T]) error {
+	results := make(chan recvResult[T])
+	go func() {
+		for {
+			msg, err := recv.Recv()
+			select {
+			case results <- recvResult[T]{msg: msg, err: err}:
+			case <-streamCtx.Done():
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// a nil heartbeat channel never fires, which disables the timeout
+	var timer *time.Timer
+	var heartbeat <-chan time.Time
+	if s.config.HeartbeatTimeout > 0 {
+		timer = time.NewTimer(s.config.HeartbeatTimeout)
+		defer timer.Stop()
+		heartbeat = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-heartbeat:
+			return ErrStreamStalled
+		case res := <-results:
+			if res.err != nil {
+				return res.err
+			}
+
+			if !s.deliver(ctx, res.msg) {
+				return ctx.Err()
+			}
+
+			if timer != nil {
+				timer.Reset(s.config.HeartbeatTimeout)
+			}
+		}
+	}
+}
+
+// deliver hands msg to the consumer according to the overflow policy, it returns false once ctx is done.
+func (s *ResilientStream[T]) deliver(ctx context.Context, msg T) bool {
+	s.stats.Received.Add(1)
+
+	policy := s.config.OverflowPolicy
+	// without a buffer there is nothing older to drop
+	if policy == DropOldest && cap(s.data) == 0 {
+		policy = DropNewest
+	}
+
+	switch policy {
+	case DropNewest:
+		select {
+		case s.data <- msg:
+		default:
+			s.drop()
+		}
+		return true
+	case DropOldest:
+		for {
+			select {
+			case s.data <- msg:
+				return true
+			default:
+			}
+
+			// the consumer may drain the buffer in between, in which case nothing is dropped
+			select {
+			case <-s.data:
+				s.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case s.data <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (s *ResilientStream[T]) emit(event StreamEvent) {
+	event.Stream = s.config.Name
+	event.Time = time.Now()
+	s.config.Events.Publish(event)
+
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+func (s *ResilientStream[T]) drop() {
+	total := s.stats.Dropped.Add(1)
+	s.config.Events.Publish(DroppedEvent{Stream: s.config.Name, Total: total, Time: time.Now()})
+}
+
+func (s *ResilientStream[T]) dispatchErr(err error) {
+	DispatchError(s.errs, err)
+}
+
+type mappedReceiver[T, U any] struct {
+	recv Receiver[T]
+	fn   func(T) U
+}
+
+func (r mappedReceiver[T, U]) Recv() (U, error) {
+	msg, err := r.recv.Recv()
+	if err != nil {
+		var zero U
+		return zero, err
+	}
+
+	return r.fn(msg), nil
+}
+
+// MapReceiver adapts recv so that every received message goes through fn.
+func MapReceiver[T, U any](recv Receiver[T], fn func(T) U) Receiver[U] {
+	return mappedReceiver[T, U]{recv: recv, fn: fn}
+}
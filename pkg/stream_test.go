@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeReceiver struct {
+	ctx  context.Context
+	msgs []int
+	err  error
+}
+
+func (r *fakeReceiver) Recv() (int, error) {
+	if len(r.msgs) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		// behaves like a silent stream until its context is cancelled
+		<-r.ctx.Done()
+		return 0, r.ctx.Err()
+	}
+
+	msg := r.msgs[0]
+	r.msgs = r.msgs[1:]
+	return msg, nil
+}
+
+func TestResilientStream(t *testing.T) {
+	backoff := WithBackoff(Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2})
+
+	t.Run("ReopenOnError", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var opened int
+		stream, err := NewResilientStream(ctx, func(ctx context.Context) (Receiver[int], error) {
+			opened++
+			return &fakeReceiver{ctx: ctx, msgs: []int{opened}, err: errors.New("broken stream")}, nil
+		}, backoff)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		for i := 1; i <= 3; i++ {
+			assert.Equal(t, i, <-stream.Data())
+		}
+		assert.GreaterOrEqual(t, stream.Reconnects(), uint64(2))
+
+		cancel()
+		for range stream.Data() {
+		}
+	})
+
+	t.Run("ReopenOnHeartbeatTimeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := NewResilientStream(ctx, func(ctx context.Context) (Receiver[int], error) {
+			return &fakeReceiver{ctx: ctx, msgs: []int{1}}, nil
+		}, backoff, WithHeartbeatTimeout(20*time.Millisecond))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, 1, <-stream.Data())
+		assert.Equal(t, 1, <-stream.Data())
+		assert.ErrorIs(t, <-stream.Errors(), ErrStreamStalled)
+	})
+
+	t.Run("InitialOpenError", func(t *testing.T) {
+		_, err := NewResilientStream(context.Background(), func(ctx context.Context) (Receiver[int], error) {
+			return nil, errors.New("unavailable")
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestResilientStreamOverflow(t *testing.T) {
+	open := func(ctx context.Context) (Receiver[int], error) {
+		return &fakeReceiver{ctx: ctx, msgs: []int{1, 2, 3, 4, 5}}, nil
+	}
+
+	for name, tc := range map[string]struct {
+		policy   OverflowPolicy
+		expected []int
+	}{
+		"DropOldest": {policy: DropOldest, expected: []int{4, 5}},
+		"DropNewest": {policy: DropNewest, expected: []int{1, 2}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var stats StreamStats
+			stream, err := NewResilientStream(ctx, open, WithBuffer(2, tc.policy), WithStats(&stats))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			assert.Eventually(t, func() bool { return stats.Received.Load() == 5 }, time.Second, time.Millisecond)
+			assert.Equal(t, tc.expected, []int{<-stream.Data(), <-stream.Data()})
+			assert.Equal(t, uint64(3), stream.Dropped())
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	assert.Equal(t, time.Duration(0), backoff.Duration(0))
+	assert.Equal(t, 100*time.Millisecond, backoff.Duration(1))
+	assert.Equal(t, 400*time.Millisecond, backoff.Duration(3))
+	assert.Equal(t, time.Second, backoff.Duration(10))
+}
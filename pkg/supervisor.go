@@ -0,0 +1,282 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxBackoffResets is the amount of consecutive transient failures tolerated before the connection is recreated.
+const maxBackoffResets = 5
+
+// ErrSupervisorClosed is returned by Reconnect once the supervisor is closed.
+var ErrSupervisorClosed = errors.New("connection supervisor is closed")
+
+// ConnStateEvent is emitted by a ConnSupervisor every time the connectivity state of its connection changes.
+type ConnStateEvent struct {
+	Target string
+	From   connectivity.State
+	To     connectivity.State
+	Time   time.Time
+}
+
+// ConnStats holds the counters of a ConnSupervisor.
+type ConnStats struct {
+	Reconnects    uint64 // amount of times the connection has been recreated.
+	BackoffResets uint64 // amount of times the connect backoff has been reset.
+	StateChanges  uint64
+	LastReconnect time.Time
+}
+
+// ConnSupervisor owns a gRPC connection, observes its connectivity state and recreates it when it dies.
+// It implements grpc.ClientConnInterface, service clients built on top of it always use the live connection.
+// Callbacks registered with OnReconnect are called with the new connection so that streams can be re-opened.
+type ConnSupervisor struct {
+	target string
+	opts   []grpc.DialOption
+	chErr  chan error
+
+	mu    sync.RWMutex
+	conn  *grpc.ClientConn
+	hooks []func(conn *grpc.ClientConn)
+
+	reconnectMu sync.Mutex // serializes reconnections triggered by the observer and by callers.
+
+	events chan ConnStateEvent
+	bus    atomic.Pointer[EventBus]
+
+	reconnects    atomic.Uint64
+	backoffResets atomic.Uint64
+	stateChanges  atomic.Uint64
+	lastReconnect atomic.Int64
+
+	closed   bool // guarded by reconnectMu.
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+}
+
+// NewConnSupervisor creates a new gRPC connection to target and starts observing it until ctx is done or Close is called.
+// Errors happening while recreating the connection are dispatched to chErr, which may be nil.
+func NewConnSupervisor(ctx context.Context, chErr chan error, target string, opts ...grpc.DialOption) (*ConnSupervisor, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &ConnSupervisor{
+		target: target,
+		opts:   opts,
+		chErr:  chErr,
+		conn:   conn,
+		events: make(chan ConnStateEvent, 16),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go s.observe(ctx)
+
+	return s, nil
+}
+
+// Conn returns the current gRPC connection.
+func (s *ConnSupervisor) Conn() *grpc.ClientConn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conn
+}
+
+// Target returns the target the connection is dialed to.
+func (s *ConnSupervisor) Target() string {
+	return s.target
+}
+
+// Invoke performs a unary RPC on the current connection.
+func (s *ConnSupervisor) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	return s.Conn().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream opens a stream on the current connection.
+func (s *ConnSupervisor) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return s.Conn().NewStream(ctx, desc, method, opts...)
+}
+
+// OnReconnect registers a callback called with the new connection each time the connection is recreated.
+func (s *ConnSupervisor) OnReconnect(fn func(conn *grpc.ClientConn)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// SetEventBus publishes the state changes, reconnections and errors of the supervisor on bus.
+func (s *ConnSupervisor) SetEventBus(bus *EventBus) {
+	s.bus.Store(bus)
+}
+
+// StateChanges returns a channel receiving connectivity state changes.
+// The channel is buffered, events are dropped if it is not drained.
+func (s *ConnSupervisor) StateChanges() <-chan ConnStateEvent {
+	return s.events
+}
+
+// Stats returns a snapshot of the supervisor counters.
+func (s *ConnSupervisor) Stats() ConnStats {
+	stats := ConnStats{
+		Reconnects:    s.reconnects.Load(),
+		BackoffResets: s.backoffResets.Load(),
+		StateChanges:  s.stateChanges.Load(),
+	}
+
+	if last := s.lastReconnect.Load(); last != 0 {
+		stats.LastReconnect = time.Unix(0, last)
+	}
+
+	return stats
+}
+
+// SetDialOptions replaces the dial options used by the next reconnections, e.g. to switch proxy.
+func (s *ConnSupervisor) SetDialOptions(opts ...grpc.DialOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opts = opts
+}
+
+// Reconnect closes the current connection and replaces it with a new one. The OnReconnect callbacks are called
+// afterward, they may reconnect or close the supervisor.
+func (s *ConnSupervisor) Reconnect() error {
+	conn, hooks, err := s.replaceConn()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		// a reconnection made meanwhile, e.g. by a previous hook, calls the hooks with its own connection
+		if conn != s.Conn() {
+			break
+		}
+		hook(conn)
+	}
+	s.bus.Load().Publish(ReconnectEvent{Target: s.target, Time: time.Now()})
+
+	return nil
+}
+
+// replaceConn swaps the connection for a new one and returns it along with the hooks to call.
+func (s *ConnSupervisor) replaceConn() (*grpc.ClientConn, []func(conn *grpc.ClientConn), error) {
+	s.reconnectMu.Lock()
+	defer s.reconnectMu.Unlock()
+
+	if s.closed {
+		return nil, nil, ErrSupervisorClosed
+	}
+
+	s.mu.RLock()
+	opts := s.opts
+	s.mu.RUnlock()
+
+	conn, err := grpc.NewClient(s.target, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	old := s.conn
+	s.conn = conn
+	hooks := make([]func(conn *grpc.ClientConn), len(s.hooks))
+	copy(hooks, s.hooks)
+	s.mu.Unlock()
+
+	s.reconnects.Add(1)
+	s.lastReconnect.Store(time.Now().UnixNano())
+
+	old.Close()
+	return conn, hooks, nil
+}
+
+// Close stops observing the connection and closes it. It waits for the observer to exit and can be called several times.
+func (s *ConnSupervisor) Close() error {
+	s.reconnectMu.Lock()
+	s.closed = true
+	s.reconnectMu.Unlock()
+
+	s.cancel()
+	<-s.done
+	return s.closeErr
+}
+
+func (s *ConnSupervisor) observe(ctx context.Context) {
+	defer close(s.done)
+
+	var retries int
+	for {
+		conn := s.Conn()
+		state := conn.GetState()
+
+		switch state {
+		case connectivity.Ready:
+			retries = 0
+		case connectivity.Idle:
+			conn.Connect()
+		case connectivity.TransientFailure:
+			if retries < maxBackoffResets {
+				retries++
+				conn.ResetConnectBackoff()
+				s.backoffResets.Add(1)
+			} else {
+				retries = 0
+				s.reconnect(ctx)
+			}
+		case connectivity.Shutdown:
+			retries = 0
+			s.reconnect(ctx)
+		}
+
+		// the connection may have been swapped by a reconnect, we only wait on the live one
+		if conn != s.Conn() {
+			continue
+		}
+
+		// WaitForStateChange only returns false once ctx is done
+		if !conn.WaitForStateChange(ctx, state) {
+			s.closeErr = s.Conn().Close()
+			return
+		}
+
+		s.stateChanges.Add(1)
+		s.emit(ConnStateEvent{Target: s.target, From: state, To: conn.GetState(), Time: time.Now()})
+	}
+}
+
+func (s *ConnSupervisor) reconnect(ctx context.Context) {
+	if err := s.Reconnect(); err != nil {
+		if errors.Is(err, ErrSupervisorClosed) {
+			return
+		}
+		s.dispatchErr(err)
+		// avoids spinning on a connection stuck in shutdown
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *ConnSupervisor) emit(event ConnStateEvent) {
+	s.bus.Load().Publish(event)
+
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+func (s *ConnSupervisor) dispatchErr(err error) {
+	s.bus.Load().Publish(ErrorEvent{Err: err, Time: time.Now()})
+
+	DispatchError(s.chErr, err)
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newHealthServer(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestConnSupervisor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	supervisor, err := NewConnSupervisor(ctx, nil, newHealthServer(t), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer supervisor.Close()
+
+	chConn := make(chan *grpc.ClientConn, 1)
+	supervisor.OnReconnect(func(conn *grpc.ClientConn) {
+		chConn <- conn
+	})
+
+	var reenter atomic.Bool
+	chReenter := make(chan error, 1)
+	supervisor.OnReconnect(func(*grpc.ClientConn) {
+		if reenter.CompareAndSwap(true, false) {
+			chReenter <- supervisor.Reconnect()
+		}
+	})
+
+	healthClient := grpc_health_v1.NewHealthClient(supervisor)
+	_, err = healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+
+	t.Run("RebindOnShutdown", func(t *testing.T) {
+		old := supervisor.Conn()
+		old.Close()
+
+		select {
+		case conn := <-chConn:
+			assert.True(t, old != conn)
+			assert.True(t, conn == supervisor.Conn())
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+
+		_, err = healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), supervisor.Stats().Reconnects)
+	})
+
+	t.Run("ReconnectFromHook", func(t *testing.T) {
+		reenter.Store(true)
+		done := make(chan error, 1)
+		go func() { done <- supervisor.Reconnect() }()
+
+		first, second := <-chConn, <-chConn
+		assert.True(t, first != second)
+		assert.True(t, second == supervisor.Conn())
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			assert.NoError(t, <-chReenter)
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+		assert.Equal(t, uint64(3), supervisor.Stats().Reconnects)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		assert.NoError(t, supervisor.Close())
+		_, err = healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		assert.Error(t, err)
+
+		assert.ErrorIs(t, supervisor.Reconnect(), ErrSupervisorClosed)
+		assert.NoError(t, supervisor.Close())
+	})
+}
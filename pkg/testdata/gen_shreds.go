@@ -0,0 +1,476 @@
+//go:build ignore
+
+// gen_shreds writes the shred fixtures replayed by the package tests. Shreds are laid out the way the Agave shredder
+// does, independently of package pkg: entries are serialized, split into data shreds, erasure coded with a
+// Reed-Solomon code built here from a Vandermonde matrix, and merkle shreds are signed over the root of their
+// erasure set. Each fixture is a gzip stream of shreds, each prefixed by its little-endian uint16 size, in which
+// shreds captured from the network can be dropped in.
+//
+//	go run gen_shreds.go
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"log"
+	"math/bits"
+	"os"
+)
+
+const (
+	sizeOfSignature      = 64
+	sizeOfDataHeaders    = 88
+	sizeOfCodingHeaders  = 89
+	sizeOfMerkleRoot     = 32
+	sizeOfProofEntry     = 20
+	codeShredPayloadSize = 1228
+	dataShredPayloadSize = codeShredPayloadSize - sizeOfCodingHeaders + sizeOfSignature
+
+	flagDataComplete = 0b0100_0000
+	flagLastInSlot   = 0b1100_0000
+
+	version = 50093
+)
+
+// erasureBatchSize is ERASURE_BATCH_SIZE of the Agave shredder, the size of an erasure set by its data shreds.
+var erasureBatchSize = [...]int{
+	0, 18, 20, 22, 23, 25, 27, 28, 30,
+	32, 33, 35, 36, 38, 39, 41, 42,
+	43, 45, 46, 48, 49, 51, 52, 53,
+	55, 56, 58, 59, 60, 62, 63, 64,
+}
+
+var (
+	leader        = key("leader")
+	retransmitter = key("retransmitter")
+	blockhash     = solana.Hash(sha256.Sum256([]byte("blockhash")))
+)
+
+func key(seed string) ed25519.PrivateKey {
+	s := sha256.Sum256([]byte(seed))
+	return ed25519.NewKeyFromSeed(s[:])
+}
+
+func main() {
+	const slot = 310_000_000
+
+	// the first erasure set of the slot chains to the last one of its parent
+	parentRoot := sha256.Sum256([]byte("parent slot"))
+	chained := shredMerkle(slot, 0, 0, 41, false, false, parentRoot, serialize(
+		tick(12500, "tick 0"),
+		transfers("entry 1", 0, 12),
+		transfers("entry 2", 12, 13),
+		transfers("entry 3", 25, 5),
+	))
+	resigned := shredMerkle(slot, chained.nextData, chained.nextCode, 63, true, true, chained.root, serialize(
+		transfers("entry 4", 30, 10),
+		tick(12500, "tick 1"),
+		tick(12500, "tick 2"),
+	))
+	legacy := shredLegacy(150_000_000, 12, serialize(
+		transfers("legacy entry 0", 40, 10),
+		tick(12500, "legacy tick 0"),
+	))
+
+	write("merkle_chained.bin.gz", chained.shreds)
+	write("merkle_chained_resigned.bin.gz", resigned.shreds)
+	write("legacy.bin.gz", legacy)
+
+	fmt.Printf("chained root %x\nresigned root %x\n", chained.root, resigned.root)
+}
+
+type entry struct {
+	numHashes uint64
+	hash      [32]byte
+	txs       []*solana.Transaction
+}
+
+func tick(numHashes uint64, seed string) entry {
+	return entry{numHashes: numHashes, hash: sha256.Sum256([]byte(seed))}
+}
+
+// transfers is an entry of n transfers, paid by the payers first to first+n.
+func transfers(seed string, first, n int) entry {
+	e := entry{numHashes: 1, hash: sha256.Sum256([]byte(seed))}
+	for i := first; i < first+n; i++ {
+		payer := solana.PrivateKey(key(fmt.Sprintf("payer %d", i)))
+		to := solana.PrivateKey(key(fmt.Sprintf("recipient %d", i))).PublicKey()
+		tx, err := solana.NewTransaction([]solana.Instruction{
+			system.NewTransferInstruction(uint64(i+1)*1_000_000, payer.PublicKey(), to).Build(),
+		}, blockhash, solana.TransactionPayer(payer.PublicKey()))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err = tx.Sign(func(solana.PublicKey) *solana.PrivateKey { return &payer }); err != nil {
+			log.Fatal(err)
+		}
+		e.txs = append(e.txs, tx)
+	}
+	return e
+}
+
+// serialize encodes entries the way bincode encodes a Vec<Entry>.
+func serialize(entries ...entry) []byte {
+	data := binary.LittleEndian.AppendUint64(nil, uint64(len(entries)))
+	for _, e := range entries {
+		data = binary.LittleEndian.AppendUint64(data, e.numHashes)
+		data = append(data, e.hash[:]...)
+		data = binary.LittleEndian.AppendUint64(data, uint64(len(e.txs)))
+		for _, tx := range e.txs {
+			raw, err := tx.MarshalBinary()
+			if err != nil {
+				log.Fatal(err)
+			}
+			data = append(data, raw...)
+		}
+	}
+	return data
+}
+
+func putCommonHeader(payload []byte, variant byte, slot uint64, index, fecSetIndex uint32) {
+	payload[64] = variant
+	binary.LittleEndian.PutUint64(payload[65:], slot)
+	binary.LittleEndian.PutUint32(payload[73:], index)
+	binary.LittleEndian.PutUint16(payload[77:], version)
+	binary.LittleEndian.PutUint32(payload[79:], fecSetIndex)
+}
+
+func putDataHeader(payload []byte, parentOffset uint16, flags byte, size int) {
+	binary.LittleEndian.PutUint16(payload[83:], parentOffset)
+	payload[85] = flags
+	binary.LittleEndian.PutUint16(payload[86:], uint16(size))
+}
+
+func putCodingHeader(payload []byte, numData, numCoding, position int) {
+	binary.LittleEndian.PutUint16(payload[83:], uint16(numData))
+	binary.LittleEndian.PutUint16(payload[85:], uint16(numCoding))
+	binary.LittleEndian.PutUint16(payload[87:], uint16(position))
+}
+
+// chunk splits data into shreds of at most capacity bytes.
+func chunk(data []byte, capacity int) [][]byte {
+	var chunks [][]byte
+	for len(data) > 0 {
+		n := min(capacity, len(data))
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	return chunks
+}
+
+type erasureSet struct {
+	shreds   [][]byte
+	root     [32]byte
+	nextData uint32
+	nextCode uint32
+}
+
+// shredMerkle shreds a batch of entries into a single merkle erasure set, the last one of the slot if last.
+func shredMerkle(slot uint64, fecSetIndex, codeIndex uint32, referenceTick byte, last, resigned bool, chainedRoot [32]byte, data []byte) erasureSet {
+	// the proof size depends on the size of the set, which depends on the capacity of the shreds: the capacity is
+	// first computed for the largest proof
+	numData := 0
+	proofSize := 6
+	for {
+		numData = len(chunk(data, merkleCapacity(dataShredPayloadSize, sizeOfDataHeaders, proofSize, resigned)))
+		if size := bits.Len(uint(erasureBatchSize[numData] - 1)); size != proofSize {
+			proofSize = size
+			continue
+		}
+		break
+	}
+	numCoding := erasureBatchSize[numData] - numData
+
+	variantData, variantCode := byte(0x90), byte(0x60)
+	if resigned {
+		variantData, variantCode = 0xB0, 0x70
+	}
+	variantData |= byte(proofSize)
+	variantCode |= byte(proofSize)
+
+	dataCapacity := merkleCapacity(dataShredPayloadSize, sizeOfDataHeaders, proofSize, resigned)
+	codeCapacity := merkleCapacity(codeShredPayloadSize, sizeOfCodingHeaders, proofSize, resigned)
+
+	var shreds [][]byte
+	var shards [][]byte
+	chunks := chunk(data, dataCapacity)
+	for i, c := range chunks {
+		flags := referenceTick
+		if i == len(chunks)-1 {
+			flags |= flagDataComplete
+			if last {
+				flags |= flagLastInSlot
+			}
+		}
+
+		payload := make([]byte, dataShredPayloadSize)
+		putCommonHeader(payload, variantData, slot, fecSetIndex+uint32(i), fecSetIndex)
+		putDataHeader(payload, 1, flags, sizeOfDataHeaders+len(c))
+		copy(payload[sizeOfDataHeaders:], c)
+		copy(payload[sizeOfDataHeaders+dataCapacity:], chainedRoot[:])
+
+		shreds = append(shreds, payload)
+		shards = append(shards, payload[sizeOfSignature:sizeOfDataHeaders+dataCapacity+sizeOfMerkleRoot])
+	}
+
+	parity := encodeParity(shards, numCoding)
+	for position, p := range parity {
+		payload := make([]byte, codeShredPayloadSize)
+		putCommonHeader(payload, variantCode, slot, codeIndex+uint32(position), fecSetIndex)
+		putCodingHeader(payload, numData, numCoding, position)
+		copy(payload[sizeOfCodingHeaders:], p)
+
+		// every data shard ends with the same chained root, the rows of the systematic matrix summing to 1 it is
+		// carried unchanged by the parity
+		if !bytes.Equal(payload[sizeOfCodingHeaders+codeCapacity:][:sizeOfMerkleRoot], chainedRoot[:]) {
+			log.Fatal("chained root not preserved by the parity")
+		}
+		shreds = append(shreds, payload)
+	}
+
+	// leaves are the shreds from their common header to their merkle proof
+	leaves := make([][32]byte, len(shreds))
+	for i, payload := range shreds {
+		capacity, headers := dataCapacity, sizeOfDataHeaders
+		if i >= numData {
+			capacity, headers = codeCapacity, sizeOfCodingHeaders
+		}
+		leaves[i] = hashv([]byte("\x00SOLANA_MERKLE_SHREDS_LEAF"), payload[sizeOfSignature:headers+capacity+sizeOfMerkleRoot])
+	}
+	tree := merkleTree(leaves)
+	root := tree[len(tree)-1]
+	signature := ed25519.Sign(leader, root[:])
+
+	for i, payload := range shreds {
+		capacity, headers := dataCapacity, sizeOfDataHeaders
+		if i >= numData {
+			capacity, headers = codeCapacity, sizeOfCodingHeaders
+		}
+
+		offset := headers + capacity + sizeOfMerkleRoot
+		proof := merkleProof(tree, len(leaves), i)
+		if len(proof) != proofSize {
+			log.Fatalf("proof of %d entries for a proof size of %d", len(proof), proofSize)
+		}
+		for _, node := range proof {
+			offset += copy(payload[offset:], node[:sizeOfProofEntry])
+		}
+
+		copy(payload, signature)
+		if resigned {
+			copy(payload[offset:], ed25519.Sign(retransmitter, root[:]))
+		}
+	}
+
+	return erasureSet{shreds: shreds, root: root, nextData: fecSetIndex + uint32(numData), nextCode: codeIndex + uint32(numCoding)}
+}
+
+func merkleCapacity(payloadSize, headers, proofSize int, resigned bool) int {
+	capacity := payloadSize - headers - sizeOfMerkleRoot - proofSize*sizeOfProofEntry
+	if resigned {
+		capacity -= sizeOfSignature
+	}
+	return capacity
+}
+
+func hashv(data ...[]byte) [32]byte {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// merkleTree is make_merkle_tree of the Agave shredder: the leaves followed by each level up to the root, the
+// last node of an odd level being joined with itself.
+func merkleTree(nodes [][32]byte) [][32]byte {
+	size := len(nodes)
+	for size > 1 {
+		offset := len(nodes) - size
+		for index := offset; index < offset+size; index += 2 {
+			other := min(index+1, offset+size-1)
+			nodes = append(nodes, hashv([]byte("\x01SOLANA_MERKLE_SHREDS_NODE"), nodes[index][:sizeOfProofEntry], nodes[other][:sizeOfProofEntry]))
+		}
+		size = len(nodes) - offset - size
+	}
+	return nodes
+}
+
+func merkleProof(tree [][32]byte, size, index int) [][32]byte {
+	var proof [][32]byte
+	offset := 0
+	for size > 1 {
+		proof = append(proof, tree[offset+min(index^1, size-1)])
+		offset += size
+		size = (size + 1) >> 1
+		index >>= 1
+	}
+	return proof
+}
+
+// shredLegacy shreds a batch of entries ending the slot into a single legacy erasure set, with as many coding
+// shreds as data shreds.
+func shredLegacy(slot uint64, referenceTick byte, data []byte) [][]byte {
+	const shardSize = codeShredPayloadSize - sizeOfCodingHeaders
+	const capacity = shardSize - sizeOfDataHeaders
+
+	var shreds [][]byte
+	var shards [][]byte
+	chunks := chunk(data, capacity)
+	for i, c := range chunks {
+		flags := referenceTick
+		if i == len(chunks)-1 {
+			flags |= flagLastInSlot
+		}
+
+		payload := make([]byte, codeShredPayloadSize)
+		putCommonHeader(payload, 0b1010_0101, slot, uint32(i), 0)
+		putDataHeader(payload, 1, flags, sizeOfDataHeaders+len(c))
+		copy(payload[sizeOfDataHeaders:], c)
+		copy(payload, ed25519.Sign(leader, payload[sizeOfSignature:]))
+
+		shreds = append(shreds, payload)
+		// legacy data shreds are erasure coded with their signature
+		shards = append(shards, payload[:shardSize])
+	}
+
+	numData := len(chunks)
+	for position, p := range encodeParity(shards, numData) {
+		payload := make([]byte, codeShredPayloadSize)
+		putCommonHeader(payload, 0b0101_1010, slot, uint32(position), 0)
+		putCodingHeader(payload, numData, numData, position)
+		copy(payload[sizeOfCodingHeaders:], p)
+		copy(payload, ed25519.Sign(leader, payload[sizeOfSignature:]))
+		shreds = append(shreds, payload)
+	}
+	return shreds
+}
+
+// GF(2^8) with the polynomial x^8+x^4+x^3+x^2+1 of reed-solomon-erasure, which Agave erasure codes shreds with.
+var gfExp, gfLog [256]byte
+
+func init() {
+	x := 1
+	for i := range 255 {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[(255-int(gfLog[a]))%255]
+}
+
+func gfPow(a byte, n int) byte {
+	r := byte(1)
+	for range n {
+		r = gfMul(r, a)
+	}
+	return r
+}
+
+// encodeParity computes the parity shards of data: the rows below the identity of the Vandermonde matrix of the
+// set made systematic by multiplying it with the inverse of its top square.
+func encodeParity(data [][]byte, numParity int) [][]byte {
+	numData := len(data)
+	vandermonde := make([][]byte, numData+numParity)
+	for r := range vandermonde {
+		vandermonde[r] = make([]byte, numData)
+		for c := range numData {
+			vandermonde[r][c] = gfPow(byte(r), c)
+		}
+	}
+	top := invert(vandermonde[:numData])
+
+	parity := make([][]byte, numParity)
+	for i := range parity {
+		row := make([]byte, numData)
+		for c := range numData {
+			for k := range numData {
+				row[c] ^= gfMul(vandermonde[numData+i][k], top[k][c])
+			}
+		}
+
+		parity[i] = make([]byte, len(data[0]))
+		for j, shard := range data {
+			for k, b := range shard {
+				parity[i][k] ^= gfMul(row[j], b)
+			}
+		}
+	}
+	return parity
+}
+
+// invert inverts a square matrix with Gauss-Jordan elimination.
+func invert(m [][]byte) [][]byte {
+	n := len(m)
+	work := make([][]byte, n)
+	for r := range n {
+		work[r] = make([]byte, 2*n)
+		copy(work[r], m[r])
+		work[r][n+r] = 1
+	}
+
+	for c := range n {
+		pivot := c
+		for work[pivot][c] == 0 {
+			pivot++
+		}
+		work[c], work[pivot] = work[pivot], work[c]
+
+		scale := gfInv(work[c][c])
+		for k := range work[c] {
+			work[c][k] = gfMul(work[c][k], scale)
+		}
+		for r := range n {
+			if r == c || work[r][c] == 0 {
+				continue
+			}
+			factor := work[r][c]
+			for k := range work[r] {
+				work[r][k] ^= gfMul(factor, work[c][k])
+			}
+		}
+	}
+
+	inverse := make([][]byte, n)
+	for r := range n {
+		inverse[r] = work[r][n:]
+	}
+	return inverse
+}
+
+func write(name string, shreds [][]byte) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	for _, shred := range shreds {
+		w.Write(binary.LittleEndian.AppendUint16(nil, uint16(len(shred))))
+		w.Write(shred)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -17,12 +17,16 @@ type AuthenticationService struct {
 	GrpcCtx     context.Context
 	KeyPair     *Keypair
 	BearerToken string
-	ExpiresAt   int64 // seconds
-	ErrChan     chan error
+	ExpiresAt   int64      // seconds
+	ErrChan     chan error // Deprecated: refresh failures are published as TokenEvent on Events.
+	Events      *EventBus  // receives a TokenEvent after every refresh, may be nil.
 	mu          sync.Mutex
+
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the refresher has exited.
 }
 
-func NewAuthenticationService(grpcConn *grpc.ClientConn, privateKey solana.PrivateKey) *AuthenticationService {
+func NewAuthenticationService(grpcConn grpc.ClientConnInterface, privateKey solana.PrivateKey) *AuthenticationService {
 	return &AuthenticationService{
 		GrpcCtx:     context.Background(),
 		AuthService: jito_pb.NewAuthServiceClient(grpcConn),
@@ -34,7 +38,15 @@ func NewAuthenticationService(grpcConn *grpc.ClientConn, privateKey solana.Priva
 
 // AuthenticateAndRefresh is a function that authenticates the client and refreshes the access token.
 func (as *AuthenticationService) AuthenticateAndRefresh(role jito_pb.Role) error {
-	respChallenge, err := as.AuthService.GenerateAuthChallenge(as.GrpcCtx,
+	return as.AuthenticateAndRefreshContext(context.Background(), role)
+}
+
+// AuthenticateAndRefreshContext authenticates the client and refreshes the access token until ctx is done or Close is called.
+func (as *AuthenticationService) AuthenticateAndRefreshContext(ctx context.Context, role jito_pb.Role) error {
+	// a previous refresher would keep overwriting the new token
+	as.Close()
+
+	respChallenge, err := as.AuthService.GenerateAuthChallenge(ctx,
 		&jito_pb.GenerateAuthChallengeRequest{
 			Role:   role,
 			Pubkey: as.KeyPair.PublicKey.Bytes(),
@@ -51,7 +63,7 @@ func (as *AuthenticationService) AuthenticateAndRefresh(role jito_pb.Role) error
 		return err
 	}
 
-	respToken, err := as.AuthService.GenerateAuthTokens(as.GrpcCtx, &jito_pb.GenerateAuthTokensRequest{
+	respToken, err := as.AuthService.GenerateAuthTokens(ctx, &jito_pb.GenerateAuthTokensRequest{
 		Challenge:       challenge,
 		SignedChallenge: sig,
 		ClientPubkey:    as.KeyPair.PublicKey.Bytes(),
@@ -62,26 +74,65 @@ func (as *AuthenticationService) AuthenticateAndRefresh(role jito_pb.Role) error
 
 	as.updateAuthorizationMetadata(respToken.AccessToken)
 
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	as.mu.Lock()
+	as.cancel, as.done = cancel, done
+	as.mu.Unlock()
+
 	go func() {
-		for {
+		defer close(done)
+		as.refresh(ctx, respToken.RefreshToken.Value, respToken.AccessToken.ExpiresAtUtc.AsTime())
+	}()
+
+	return nil
+}
+
+// refresh renews the access token 15 seconds before it expires, retrying every 5 seconds on failure.
+func (as *AuthenticationService) refresh(ctx context.Context, refreshToken string, expiresAt time.Time) {
+	wait := max(time.Until(expiresAt)-15*time.Second, time.Second)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		resp, err := as.AuthService.RefreshAccessToken(ctx, &jito_pb.RefreshAccessTokenRequest{
+			RefreshToken: refreshToken,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			err = fmt.Errorf("failed to refresh access token: %w", err)
+			as.Events.Publish(TokenEvent{Err: err, Time: time.Now()})
 			select {
-			case <-as.GrpcCtx.Done():
-				as.ErrChan <- as.GrpcCtx.Err()
+			case as.ErrChan <- err:
 			default:
-				var resp *jito_pb.RefreshAccessTokenResponse
-				resp, err = as.AuthService.RefreshAccessToken(as.GrpcCtx, &jito_pb.RefreshAccessTokenRequest{
-					RefreshToken: respToken.RefreshToken.Value,
-				})
-				if err != nil {
-					as.ErrChan <- fmt.Errorf("failed to refresh access token: %w", err)
-					continue
-				}
-
-				as.updateAuthorizationMetadata(resp.AccessToken)
-				time.Sleep(time.Until(resp.AccessToken.ExpiresAtUtc.AsTime()) - 15*time.Second)
 			}
+			wait = 5 * time.Second
+			continue
 		}
-	}()
+
+		as.updateAuthorizationMetadata(resp.AccessToken)
+		as.Events.Publish(TokenEvent{ExpiresAt: resp.AccessToken.ExpiresAtUtc.AsTime(), Time: time.Now()})
+		wait = max(time.Until(resp.AccessToken.ExpiresAtUtc.AsTime())-15*time.Second, time.Second)
+	}
+}
+
+// Close stops refreshing the access token and waits for the refresher to exit, it can be called several times.
+func (as *AuthenticationService) Close() error {
+	as.mu.Lock()
+	cancel, done := as.cancel, as.done
+	as.cancel, as.done = nil, nil
+	as.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
 
 	return nil
 }
@@ -96,6 +147,19 @@ func (as *AuthenticationService) updateAuthorizationMetadata(token *jito_pb.Toke
 	as.ExpiresAt = token.ExpiresAtUtc.Seconds
 }
 
+// AuthorizeContext returns a copy of ctx carrying the current bearer token, if any.
+func (as *AuthenticationService) AuthorizeContext(ctx context.Context) context.Context {
+	as.mu.Lock()
+	token := as.BearerToken
+	as.mu.Unlock()
+
+	if token == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
 func (as *AuthenticationService) generateChallengeSignature(challenge []byte) ([]byte, error) {
 	sig, err := as.KeyPair.PrivateKey.Sign(challenge)
 	if err != nil {
@@ -0,0 +1,373 @@
+package pkg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"github.com/gagliardetto/solana-go"
+	"github.com/quic-go/quic-go"
+	"github.com/weeaa/jito-go/pb"
+	"golang.org/x/sync/singleflight"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// TPUQUICPortOffset is the offset between the UDP port of a TPU and its QUIC port.
+	TPUQUICPortOffset = 6
+	// TPUALPN is the application protocol negotiated by QUIC TPU connections.
+	TPUALPN = "solana-tpu"
+	// MaxTPUPacketSize is the largest serialized transaction a TPU accepts.
+	MaxTPUPacketSize = 1232
+)
+
+var ErrTPUSenderClosed = errors.New("tpu sender closed")
+
+type TPUProtocol int
+
+const (
+	TPUProtocolUDP TPUProtocol = iota
+	TPUProtocolQUIC
+)
+
+func (p TPUProtocol) String() string {
+	switch p {
+	case TPUProtocolUDP:
+		return "udp"
+	case TPUProtocolQUIC:
+		return "quic"
+	default:
+		return "unknown"
+	}
+}
+
+// TPUAddr returns the address to send transactions to socket with protocol, the QUIC port being offset from the UDP one.
+func TPUAddr(socket *jito_pb.Socket, protocol TPUProtocol) (string, error) {
+	if socket.GetIp() == "" || socket.GetPort() <= 0 {
+		return "", fmt.Errorf("invalid tpu socket %s:%d", socket.GetIp(), socket.GetPort())
+	}
+
+	port := socket.GetPort()
+	if protocol == TPUProtocolQUIC {
+		port += TPUQUICPortOffset
+	}
+	if port > 65535 {
+		return "", fmt.Errorf("invalid tpu port %d", port)
+	}
+
+	return net.JoinHostPort(socket.GetIp(), strconv.Itoa(int(port))), nil
+}
+
+type TPUSenderConfig struct {
+	Protocol TPUProtocol // defaults to TPUProtocolUDP.
+
+	// PrivateKey is the identity presented in the QUIC client certificate, connections from staked
+	// identities get a larger share of the TPU bandwidth. A random identity is used when nil.
+	PrivateKey solana.PrivateKey
+
+	DialTimeout time.Duration // defaults to 5s.
+	IdleTimeout time.Duration // QUIC connections unused for that long are closed, defaults to 30s.
+}
+
+// TPUStats counts what was sent to a single destination.
+type TPUStats struct {
+	Sent      uint64 // transactions sent.
+	Failed    uint64 // transactions which could not be sent.
+	Bytes     uint64 // bytes sent.
+	LastError error
+	LastSent  time.Time
+}
+
+// TPUSender sends serialized transactions straight to TPU sockets over UDP or QUIC.
+// UDP goes through a single socket, QUIC connections are kept open and reused for each destination.
+// It is safe for concurrent use.
+type TPUSender struct {
+	config    TPUSenderConfig
+	tlsConfig *tls.Config
+
+	udp *net.UDPConn
+
+	dials  singleflight.Group
+	mu     sync.Mutex
+	conns  map[string]quic.Connection
+	stats  map[string]*TPUStats
+	closed bool
+}
+
+func NewTPUSender(config TPUSenderConfig) (*TPUSender, error) {
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 30 * time.Second
+	}
+
+	s := &TPUSender{config: config, conns: make(map[string]quic.Connection), stats: make(map[string]*TPUStats)}
+
+	switch config.Protocol {
+	case TPUProtocolUDP:
+		udp, err := net.ListenUDP("udp", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open udp socket: %w", err)
+		}
+		s.udp = udp
+	case TPUProtocolQUIC:
+		cert, err := newTPUCertificate(config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		s.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			// validators present self-signed certificates
+			InsecureSkipVerify: true,
+			NextProtos:         []string{TPUALPN},
+		}
+	default:
+		return nil, fmt.Errorf("unknown tpu protocol %d", config.Protocol)
+	}
+
+	return s, nil
+}
+
+// newTPUCertificate creates the self-signed certificate validators expect from QUIC clients, signed by key.
+func newTPUCertificate(key solana.PrivateKey) (tls.Certificate, error) {
+	var priv ed25519.PrivateKey
+	if key == nil {
+		_, generated, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		priv = generated
+	} else {
+		priv = ed25519.PrivateKey(key)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Solana node"},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Date(4096, 1, 1, 0, 0, 0, 0, time.UTC),
+		IPAddresses:  []net.IP{net.IPv4zero},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create tpu client certificate: %w", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}, nil
+}
+
+// Protocol returns the protocol the sender uses.
+func (s *TPUSender) Protocol() TPUProtocol {
+	return s.config.Protocol
+}
+
+// SendSocket is Send to the address of socket for the sender protocol.
+func (s *TPUSender) SendSocket(ctx context.Context, socket *jito_pb.Socket, txns ...*solana.Transaction) error {
+	addr, err := TPUAddr(socket, s.config.Protocol)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, addr, txns...)
+}
+
+// Send serializes txns and sends them to addr, one packet or QUIC stream per transaction.
+func (s *TPUSender) Send(ctx context.Context, addr string, txns ...*solana.Transaction) error {
+	payloads := make([][]byte, len(txns))
+	for i, tx := range txns {
+		payload, err := tx.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to serialize transaction %d: %w", i, err)
+		}
+		payloads[i] = payload
+	}
+	return s.SendRaw(ctx, addr, payloads...)
+}
+
+// SendRaw sends already serialized transactions to addr. Every payload is attempted, the errors are joined.
+func (s *TPUSender) SendRaw(ctx context.Context, addr string, payloads ...[]byte) error {
+	var errs []error
+	for _, payload := range payloads {
+		var err error
+		if len(payload) > MaxTPUPacketSize {
+			err = fmt.Errorf("transaction of %d bytes exceeds the tpu packet size of %d", len(payload), MaxTPUPacketSize)
+		} else if s.config.Protocol == TPUProtocolQUIC {
+			err = s.sendQUIC(ctx, addr, payload)
+		} else {
+			err = s.sendUDP(addr, payload)
+		}
+
+		s.record(addr, len(payload), err)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *TPUSender) sendUDP(addr string, payload []byte) error {
+	if s.isClosed() {
+		return ErrTPUSenderClosed
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.udp.WriteToUDP(payload, udpAddr)
+	return err
+}
+
+func (s *TPUSender) sendQUIC(ctx context.Context, addr string, payload []byte) error {
+	conn, err := s.conn(ctx, addr)
+	if err != nil {
+		return err
+	}
+
+	if err = writeStream(ctx, conn, payload); err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	// the reused connection may have been closed by the validator, retry once on a fresh one
+	s.drop(addr, conn)
+	if conn, err = s.conn(ctx, addr); err != nil {
+		return err
+	}
+	if err = writeStream(ctx, conn, payload); err != nil {
+		s.drop(addr, conn)
+	}
+	return err
+}
+
+func writeStream(ctx context.Context, conn quic.Connection, payload []byte) error {
+	stream, err := conn.OpenUniStreamSync(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err = stream.Write(payload); err != nil {
+		stream.CancelWrite(0)
+		return err
+	}
+	return stream.Close()
+}
+
+// conn returns the open connection to addr, dialing it when there is none.
+func (s *TPUSender) conn(ctx context.Context, addr string) (quic.Connection, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrTPUSenderClosed
+	}
+	conn, ok := s.conns[addr]
+	s.mu.Unlock()
+
+	if ok && conn.Context().Err() == nil {
+		return conn, nil
+	}
+
+	v, err, _ := s.dials.Do(addr, func() (any, error) {
+		dialCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.config.DialTimeout)
+		defer cancel()
+
+		conn, err := quic.DialAddr(dialCtx, addr, s.tlsConfig, &quic.Config{
+			MaxIdleTimeout:  s.config.IdleTimeout,
+			KeepAlivePeriod: s.config.IdleTimeout / 2,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial tpu %s: %w", addr, err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.closed {
+			conn.CloseWithError(0, "")
+			return nil, ErrTPUSenderClosed
+		}
+		s.conns[addr] = conn
+		return conn, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(quic.Connection), nil
+}
+
+func (s *TPUSender) drop(addr string, conn quic.Connection) {
+	s.mu.Lock()
+	if s.conns[addr] == conn {
+		delete(s.conns, addr)
+	}
+	s.mu.Unlock()
+
+	conn.CloseWithError(0, "")
+}
+
+func (s *TPUSender) record(addr string, size int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.stats[addr]
+	if !ok {
+		stats = &TPUStats{}
+		s.stats[addr] = stats
+	}
+
+	if err != nil {
+		stats.Failed++
+		stats.LastError = err
+		return
+	}
+	stats.Sent++
+	stats.Bytes += uint64(size)
+	stats.LastSent = time.Now()
+}
+
+func (s *TPUSender) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Stats returns a snapshot of the stats of every destination, keyed by address.
+func (s *TPUSender) Stats() map[string]TPUStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make(map[string]TPUStats, len(s.stats))
+	for addr, st := range s.stats {
+		stats[addr] = *st
+	}
+	return stats
+}
+
+// Close closes the UDP socket and every QUIC connection, the sender cannot be used afterwards.
+func (s *TPUSender) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	conns := s.conns
+	s.conns = make(map[string]quic.Connection)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, conn := range conns {
+		errs = append(errs, conn.CloseWithError(0, ""))
+	}
+	if s.udp != nil {
+		errs = append(errs, s.udp.Close())
+	}
+	return errors.Join(errs...)
+}
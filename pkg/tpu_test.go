@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"github.com/gagliardetto/solana-go"
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/weeaa/jito-go/pb"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTPUAddr(t *testing.T) {
+	addr, err := TPUAddr(&jito_pb.Socket{Ip: "127.0.0.1", Port: 8001}, TPUProtocolUDP)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8001", addr)
+
+	addr, err = TPUAddr(&jito_pb.Socket{Ip: "127.0.0.1", Port: 8001}, TPUProtocolQUIC)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8007", addr)
+
+	_, err = TPUAddr(&jito_pb.Socket{Port: 8001}, TPUProtocolUDP)
+	assert.Error(t, err)
+	_, err = TPUAddr(nil, TPUProtocolUDP)
+	assert.Error(t, err)
+}
+
+func TestTPUSenderUDP(t *testing.T) {
+	listener, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	sender, err := NewTPUSender(TPUSenderConfig{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer sender.Close()
+
+	tx := newTestTransaction(t)
+	payload, _ := tx.MarshalBinary()
+
+	addr := listener.LocalAddr().(*net.UDPAddr)
+	err = sender.SendSocket(context.Background(), &jito_pb.Socket{Ip: "127.0.0.1", Port: int64(addr.Port)}, tx, tx)
+	assert.NoError(t, err)
+
+	buf := make([]byte, 2048)
+	for i := 0; i < 2; i++ {
+		listener.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := listener.ReadFromUDP(buf)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, payload, buf[:n])
+	}
+
+	err = sender.SendRaw(context.Background(), addr.String(), make([]byte, MaxTPUPacketSize+1))
+	assert.Error(t, err)
+
+	stats := sender.Stats()[addr.String()]
+	assert.Equal(t, uint64(2), stats.Sent)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Equal(t, uint64(2*len(payload)), stats.Bytes)
+	assert.Error(t, stats.LastError)
+}
+
+func TestTPUSenderQUIC(t *testing.T) {
+	cert, err := newTPUCertificate(nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	listener, err := quic.ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		NextProtos:   []string{TPUALPN},
+	}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	type received struct {
+		identity ed25519.PublicKey
+		payload  []byte
+	}
+	chReceived := make(chan received, 8)
+	accepted := make(chan struct{}, 8)
+
+	go func() {
+		for {
+			conn, err := listener.Accept(ctx)
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+
+			identity := conn.ConnectionState().TLS.PeerCertificates[0].PublicKey.(ed25519.PublicKey)
+			go func() {
+				for {
+					stream, err := conn.AcceptUniStream(ctx)
+					if err != nil {
+						return
+					}
+					payload, err := io.ReadAll(stream)
+					if err == nil {
+						chReceived <- received{identity: identity, payload: payload}
+					}
+				}
+			}()
+		}
+	}()
+
+	wallet := solana.NewWallet()
+	sender, err := NewTPUSender(TPUSenderConfig{Protocol: TPUProtocolQUIC, PrivateKey: wallet.PrivateKey})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer sender.Close()
+
+	tx := newTestTransaction(t)
+	payload, _ := tx.MarshalBinary()
+	addr := listener.Addr().String()
+
+	assert.NoError(t, sender.Send(ctx, addr, tx))
+	assert.NoError(t, sender.Send(ctx, addr, tx))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-chReceived:
+			assert.Equal(t, payload, r.payload)
+			assert.Equal(t, ed25519.PublicKey(wallet.PublicKey().Bytes()), r.identity)
+		case <-ctx.Done():
+			t.Fatal("transaction not received")
+		}
+	}
+
+	// both transactions went through the same connection
+	assert.Len(t, accepted, 1)
+
+	stats := sender.Stats()[addr]
+	assert.Equal(t, uint64(2), stats.Sent)
+	assert.Equal(t, uint64(0), stats.Failed)
+
+	assert.NoError(t, sender.Close())
+	assert.ErrorIs(t, sender.Send(ctx, addr, tx), ErrTPUSenderClosed)
+}
@@ -106,7 +106,13 @@ func TxToStr(txns []*solana.Transaction) []string {
 	return txnsStr
 }
 
+// GetTipInformation is GetTipInformationContext with a background context.
 func GetTipInformation(client *http.Client) (*[]TipStreamInfo, error) {
+	return GetTipInformationContext(context.Background(), client)
+}
+
+// GetTipInformationContext returns the latest tip floor of landed bundles.
+func GetTipInformationContext(ctx context.Context, client *http.Client) (*[]TipStreamInfo, error) {
 	if client == nil {
 		client = &http.Client{}
 	}
@@ -119,7 +125,7 @@ func GetTipInformation(client *http.Client) (*[]TipStreamInfo, error) {
 		},
 	}
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}